@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/eval"
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/providers"
+)
+
+type evalOptions struct {
+	Cases         string   `long:"cases" description:"JSONL evaluation set to run"`
+	Label         string   `long:"label" description:"Name of the configuration being evaluated" default:"current"`
+	K             int      `long:"k" description:"Number of top results used for recall@k, defaults to the RAG top k"`
+	MinSimilarity *float64 `long:"min_similarity" description:"Overrides the RAG minimum similarity for every search, 0 keeps every chunk"`
+	MaxTokens     *int     `long:"max_tokens" description:"Overrides the RAG token budget for every search"`
+	TopK          *int     `long:"top_k" description:"Overrides the RAG top k for every search"`
+	RetrievalOnly bool     `long:"retrieval_only" description:"Only measure retrieval, without generating answers"`
+	Grade         bool     `long:"grade" description:"Grade answers for faithfulness and correctness with a Bedrock model"`
+	JudgeModelID  string   `long:"judge_model_id" description:"Bedrock model used to grade answers, defaults to the answer model"`
+	Output        string   `long:"output" description:"File to write the report to"`
+	Baseline      string   `long:"baseline" description:"Report to compare the results against"`
+	Compare       string   `long:"compare" description:"Compare this report against the baseline instead of running the evaluation set"`
+	MaxRegression float64  `long:"max_regression" description:"Largest drop in any metric allowed before the comparison fails" default:"0.02"`
+}
+
+// Runs an evaluation set through retrieval and answering, and optionally compares the
+// results with an earlier run. Exits with an error when a metric regressed.
+func main() {
+	options := &evalOptions{}
+	cfg, err := config.LoadWithOptions(options)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	var report *eval.Report
+	if options.Compare != "" {
+		report, err = readReport(options.Compare)
+	} else {
+		report, err = runEvaluation(cfg, options)
+	}
+	if err != nil {
+		log.Fatalf("Failed to evaluate: %v", err)
+	}
+
+	if err := eval.WriteSummary(os.Stdout, report); err != nil {
+		log.Fatalf("Failed to write summary: %v", err)
+	}
+
+	if options.Output != "" {
+		if err := writeReport(options.Output, report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+
+	if options.Baseline == "" {
+		return
+	}
+
+	baseline, err := readReport(options.Baseline)
+	if err != nil {
+		log.Fatalf("Failed to read baseline: %v", err)
+	}
+
+	comparison := eval.Compare(baseline, report, options.MaxRegression)
+	fmt.Println()
+	if err := eval.WriteComparison(os.Stdout, comparison); err != nil {
+		log.Fatalf("Failed to write comparison: %v", err)
+	}
+
+	if comparison.Regressed {
+		log.Fatalf("Metrics regressed by more than %.3f against %s", options.MaxRegression, baseline.Label)
+	}
+}
+
+func runEvaluation(cfg *config.Config, options *evalOptions) (*eval.Report, error) {
+	if options.Cases == "" {
+		return nil, fmt.Errorf("--cases is required unless comparing reports")
+	}
+
+	file, err := os.Open(options.Cases)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cases, err := eval.ReadCases(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := providers.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var answerProvider eval.AnswerProvider = pipeline.Answer
+	if options.RetrievalOnly {
+		answerProvider = nil
+	}
+
+	var grader eval.Grader
+	if options.Grade && answerProvider != nil {
+		grader, err = createGrader(cfg, options.JudgeModelID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	overrides := &knowledge.SearchRequest{
+		MinSimilarity: options.MinSimilarity,
+		MaxTokens:     options.MaxTokens,
+		TopK:          options.TopK,
+	}
+
+	k := options.K
+	if k <= 0 {
+		k = cfg.RAG.TopK
+	}
+
+	runner := eval.NewRunner(pipeline.Knowledge, answerProvider, grader, overrides, k)
+	return runner.Run(context.Background(), options.Label, cases), nil
+}
+
+func createGrader(cfg *config.Config, judgeModelID string) (eval.Grader, error) {
+	judgeConfig := *cfg.Bedrock
+	if judgeModelID != "" {
+		judgeConfig.ModelID = judgeModelID
+	}
+
+	judgeClient, err := aws.NewAWSBedrockClient(&judgeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create judge Bedrock client: %w", err)
+	}
+
+	return eval.NewBedrockGrader(judgeClient), nil
+}
+
+func readReport(path string) (*eval.Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return eval.ReadReport(file)
+}
+
+func writeReport(path string, report *eval.Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return eval.WriteReport(file, report)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/eval"
+	"github.com/PhilNel/go-boardgame-assistant/internal/feedback"
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
+)
+
+type exportOptions struct {
+	Output   string `long:"output" description:"File to write the evaluation set to, - for stdout" default:"-"`
+	GameName string `long:"game" description:"Only export feedback for this game"`
+	From     string `long:"from" description:"Only export feedback created on or after this date (2006-01-02)"`
+	To       string `long:"to" description:"Only export feedback created on or before this date (2006-01-02)"`
+}
+
+// Exports negative feedback as a JSONL evaluation set, one case per question
+func main() {
+	options := &exportOptions{}
+	cfg, err := config.LoadWithOptions(options)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	query, err := buildQuery(options)
+	if err != nil {
+		log.Fatalf("Invalid options: %v", err)
+	}
+
+	dynamoClient, err := aws.NewDynamoDBClient(cfg.DynamoDB)
+	if err != nil {
+		log.Fatalf("Failed to create DynamoDB client: %v", err)
+	}
+	feedbackRepo := feedback.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.FeedbackTable, cfg.DynamoDB.FeedbackIndex)
+
+	var messageRepo eval.MessageRepository
+	if cfg.DynamoDB.AnswersTable != "" {
+		messageRepo = message.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.AnswersTable)
+	}
+
+	cases, err := eval.ExportFeedback(context.Background(), feedbackRepo, messageRepo, query)
+	if err != nil {
+		log.Fatalf("Failed to export feedback: %v", err)
+	}
+
+	if err := writeOutput(options.Output, cases); err != nil {
+		log.Fatalf("Failed to write evaluation set: %v", err)
+	}
+}
+
+func buildQuery(options *exportOptions) (*feedback.FeedbackQuery, error) {
+	query := &feedback.FeedbackQuery{GameName: options.GameName}
+
+	var err error
+	if options.From != "" {
+		if query.From, err = time.Parse(time.DateOnly, options.From); err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if options.To != "" {
+		if query.To, err = time.Parse(time.DateOnly, options.To); err != nil {
+			return nil, fmt.Errorf("invalid to: %w", err)
+		}
+		query.To = query.To.Add(24*time.Hour - time.Second)
+	}
+
+	return query, nil
+}
+
+func writeOutput(path string, cases []*eval.Case) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	return eval.WriteCases(w, cases)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
 	"github.com/PhilNel/go-boardgame-assistant/internal/feedback"
 	"github.com/PhilNel/go-boardgame-assistant/internal/handler"
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
 	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -27,10 +28,15 @@ func init() {
 	if err != nil {
 		log.Fatalf("Failed to create DynamoDB client: %v", err)
 	}
-	feedbackRepo := feedback.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.FeedbackTable)
+	feedbackRepo := feedback.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.FeedbackTable, cfg.DynamoDB.FeedbackIndex)
 
-	feedbackBusinessHandler := feedback.NewHandler(feedbackRepo)
-	feedbackHandler = handler.NewFeedbackHandler(feedbackBusinessHandler)
+	var messageRepo feedback.MessageRepository
+	if cfg.DynamoDB.AnswersTable != "" {
+		messageRepo = message.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.AnswersTable)
+	}
+
+	feedbackBusinessHandler := feedback.NewHandler(feedbackRepo, messageRepo)
+	feedbackHandler = handler.NewFeedbackHandler(feedbackBusinessHandler, cfg.System.AdminAPIKey)
 
 	log.Printf("Feedback Handler Lambda initialized successfully")
 }
@@ -2,15 +2,20 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
 	"github.com/PhilNel/go-boardgame-assistant/internal/embedding"
 	"github.com/PhilNel/go-boardgame-assistant/internal/handler"
 	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/openai"
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
 	"github.com/PhilNel/go-boardgame-assistant/internal/status"
 	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	"github.com/PhilNel/go-boardgame-assistant/internal/vectorindex"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
@@ -35,21 +40,49 @@ func init() {
 	if err != nil {
 		log.Fatalf("Failed to create Bedrock client: %v", err)
 	}
-	embeddingProvider := embedding.NewBedrockCreator(bedrockClient)
-
 	dynamoClient, err := aws.NewDynamoDBClient(cfg.DynamoDB)
 	if err != nil {
 		log.Fatalf("Failed to create DynamoDB client: %v", err)
 	}
-	knowledgeRepo := knowledge.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.KnowledgeTable)
+
+	embeddingProvider, err := createEmbeddingProvider(cfg, bedrockClient)
+	if err != nil {
+		log.Fatalf("Failed to create embedding provider: %v", err)
+	}
+	if cfg.DynamoDB.EmbeddingCache != "" {
+		embeddingCache := embedding.NewDynamoDBCache(dynamoClient, cfg.DynamoDB.EmbeddingCache)
+		embeddingProvider = embedding.NewCachedCreator(embeddingProvider, embeddingCache)
+	}
+	knowledgeRepo := knowledge.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.KnowledgeTable, cfg.RAG.EmbeddingFormat)
 	statusRepo := status.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.JobsTable)
 
-	processor := knowledge.NewProcessor(fileProvider, embeddingProvider, knowledgeRepo, statusRepo, cfg.RAG)
+	indexRepo := vectorindex.NewS3Repository(s3Client, time.Duration(cfg.RAG.CacheTTLHours)*time.Hour)
+
+	var referenceRepo knowledge.ReferenceRepository
+	if cfg.DynamoDB.ReferencesTable != "" {
+		referenceRepo = references.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.ReferencesTable)
+	}
+	if cfg.RAG.UndefinedRefs != "warn" && cfg.RAG.UndefinedRefs != "fail" {
+		log.Fatalf("Unknown undefined references action: %s", cfg.RAG.UndefinedRefs)
+	}
+
+	processor := knowledge.NewProcessor(fileProvider, embeddingProvider, knowledgeRepo, statusRepo, indexRepo, referenceRepo, cfg.RAG)
 	processingHandler = handler.NewProcessingHandler(processor)
 
 	log.Printf("Knowledge Processor Lambda initialized successfully")
 }
 
+func createEmbeddingProvider(cfg *config.Config, bedrockClient aws.BedrockClient) (embedding.Creator, error) {
+	switch cfg.System.ModelProvider {
+	case "", "bedrock":
+		return embedding.NewBedrockCreator(bedrockClient, cfg.Bedrock)
+	case "openai":
+		return embedding.NewOpenAICreator(openai.NewHTTPClient(cfg.OpenAI), cfg.OpenAI), nil
+	default:
+		return nil, fmt.Errorf("unknown model provider: %s", cfg.System.ModelProvider)
+	}
+}
+
 func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	// Add panic recovery
 	defer func() {
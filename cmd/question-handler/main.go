@@ -2,15 +2,17 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
-	"github.com/PhilNel/go-boardgame-assistant/internal/answer"
 	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
-	"github.com/PhilNel/go-boardgame-assistant/internal/embedding"
+	"github.com/PhilNel/go-boardgame-assistant/internal/conversation"
+	"github.com/PhilNel/go-boardgame-assistant/internal/grounding"
 	"github.com/PhilNel/go-boardgame-assistant/internal/handler"
-	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
-	"github.com/PhilNel/go-boardgame-assistant/internal/prompt"
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
+	"github.com/PhilNel/go-boardgame-assistant/internal/providers"
 	"github.com/PhilNel/go-boardgame-assistant/internal/references"
 	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
 	"github.com/aws/aws-lambda-go/events"
@@ -18,6 +20,8 @@ import (
 )
 
 var questionHandler *handler.QuestionHandler
+var glossaryHandler *handler.GlossaryHandler
+var responseMode string
 
 func init() {
 	log.Printf("Starting Lambda initialization")
@@ -28,30 +32,68 @@ func init() {
 	}
 	log.Printf("Loaded config: %+v", cfg)
 
-	dynamoClient, err := aws.NewDynamoDBClient(cfg.DynamoDB)
+	pipeline, err := providers.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create DynamoDB client: %v", err)
+		log.Fatalf("Failed to create providers: %v", err)
 	}
-	knowledgeRepo := knowledge.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.KnowledgeTable)
+	dynamoClient := pipeline.DynamoDBClient
+	bedrockClient := pipeline.BedrockClient
+	glossaryRepo := pipeline.GlossaryRepo
 
-	bedrockClient, err := aws.NewAWSBedrockClient(cfg.Bedrock)
-	if err != nil {
-		log.Fatalf("Failed to create Bedrock client: %v", err)
+	referencesRepo := references.NewCachedRepository(references.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.ReferencesTable), time.Duration(cfg.RAG.CacheTTLHours)*time.Hour)
+	if cfg.RAG.CitationAction != "drop" && cfg.RAG.CitationAction != "flag" {
+		log.Fatalf("Unknown invalid citation action: %s", cfg.RAG.CitationAction)
+	}
+	referenceProcessor := references.NewReferenceProcessor(referencesRepo, cfg.RAG.CitationAction == "drop")
+
+	var conversationRepo conversation.Repository
+	if cfg.DynamoDB.Conversations != "" {
+		conversationRepo = conversation.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.Conversations, time.Duration(cfg.RAG.ConversationTTL)*time.Hour)
 	}
-	embeddingProvider := embedding.NewBedrockCreator(bedrockClient)
 
-	templateProvider := prompt.NewStaticTemplate()
+	var messageRepo message.Repository
+	if cfg.DynamoDB.AnswersTable != "" {
+		messageRepo = message.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.AnswersTable)
+	}
 
-	referencesRepo := references.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.ReferencesTable)
-	referenceProcessor := references.NewReferenceProcessor(referencesRepo)
+	groundingVerifier, err := createGroundingVerifier(cfg, bedrockClient)
+	if err != nil {
+		log.Fatalf("Failed to create grounding verifier: %v", err)
+	}
+	if cfg.RAG.GroundingAction != "flag" && cfg.RAG.GroundingAction != "strip" {
+		log.Fatalf("Unknown grounding action: %s", cfg.RAG.GroundingAction)
+	}
 
-	answerProvider := answer.NewBedrockProvider(bedrockClient, templateProvider, cfg.Bedrock)
-	knowledgeProvider := knowledge.NewVectorProvider(knowledgeRepo, embeddingProvider, cfg.RAG)
-	questionHandler = handler.NewQuestionHandler(knowledgeProvider, answerProvider, referenceProcessor)
+	questionHandler = handler.NewQuestionHandler(pipeline.Knowledge, pipeline.Answer, referenceProcessor, handler.QuestionHandlerOptions{
+		ConversationRepo:  conversationRepo,
+		MessageRepo:       messageRepo,
+		MaxTurns:          cfg.RAG.ConversationTurns,
+		AdminKey:          cfg.System.AdminAPIKey,
+		GroundingVerifier: groundingVerifier,
+		StripUnsupported:  cfg.RAG.GroundingAction == "strip",
+	})
+	glossaryHandler = handler.NewGlossaryHandler(glossaryRepo)
+	responseMode = cfg.System.ResponseMode
+	if responseMode != "buffered" && responseMode != "streaming" {
+		log.Fatalf("Unknown response mode: %s", responseMode)
+	}
 
 	log.Printf("Lambda initialized successfully with references support")
 }
 
+func createGroundingVerifier(cfg *config.Config, bedrockClient aws.BedrockClient) (grounding.Verifier, error) {
+	switch cfg.RAG.Grounding {
+	case "", "none":
+		return nil, nil
+	case "heuristic":
+		return grounding.NewHeuristicVerifier(cfg.RAG.KeywordShortTerms, cfg.RAG.GroundingSupport), nil
+	case "bedrock":
+		return grounding.NewBedrockVerifier(bedrockClient), nil
+	default:
+		return nil, fmt.Errorf("unknown grounding verifier: %s", cfg.RAG.Grounding)
+	}
+}
+
 func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	// Add panic recovery
 	defer func() {
@@ -60,7 +102,13 @@ func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (
 		}
 	}()
 
-	response, err := questionHandler.Handle(ctx, request)
+	var response events.APIGatewayProxyResponse
+	var err error
+	if request.HTTPMethod == "GET" {
+		response, err = glossaryHandler.Handle(ctx, request)
+	} else {
+		response, err = questionHandler.Handle(ctx, request)
+	}
 	if err != nil {
 		log.Printf("ERROR: Handler returned error: %v", err)
 		return utils.CreateErrorResponse(500, "Internal server error"), nil
@@ -69,6 +117,40 @@ func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (
 	return response, nil
 }
 
+// handleStreamRequest serves a Lambda function URL configured with the RESPONSE_STREAM invoke mode
+func handleStreamRequest(ctx context.Context, request events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC: Lambda handler panicked: %v", r)
+		}
+	}()
+
+	if request.RequestContext.HTTP.Method == "GET" {
+		response, err := glossaryHandler.Handle(ctx, events.APIGatewayProxyRequest{
+			HTTPMethod:            request.RequestContext.HTTP.Method,
+			Path:                  request.RawPath,
+			QueryStringParameters: request.QueryStringParameters,
+		})
+		if err != nil {
+			log.Printf("ERROR: Handler returned error: %v", err)
+			return utils.CreateStreamingErrorResponse(500, "Internal server error"), nil
+		}
+		return utils.ToStreamingResponse(response), nil
+	}
+
+	response, err := questionHandler.HandleStream(ctx, request)
+	if err != nil {
+		log.Printf("ERROR: Handler returned error: %v", err)
+		return utils.CreateStreamingErrorResponse(500, "Internal server error"), nil
+	}
+
+	return response, nil
+}
+
 func main() {
+	if responseMode == "streaming" {
+		lambda.Start(handleStreamRequest)
+		return
+	}
 	lambda.Start(handleRequest)
 }
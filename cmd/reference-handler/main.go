@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/handler"
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var referenceHandler *handler.ReferenceHandler
+
+func init() {
+	log.Printf("Starting Reference Handler Lambda initialization")
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	dynamoClient, err := aws.NewDynamoDBClient(cfg.DynamoDB)
+	if err != nil {
+		log.Fatalf("Failed to create DynamoDB client: %v", err)
+	}
+	referencesRepo := references.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.ReferencesTable)
+
+	s3Client, err := aws.NewS3Client(cfg.S3)
+	if err != nil {
+		log.Fatalf("Failed to create S3 client: %v", err)
+	}
+	fileProvider := knowledge.NewS3Provider(s3Client)
+
+	manager := references.NewManager(referencesRepo, fileProvider)
+	referenceHandler = handler.NewReferenceHandler(manager, cfg.System.AdminAPIKey)
+
+	log.Printf("Reference Handler Lambda initialized successfully")
+}
+
+func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Add panic recovery
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC: Lambda handler panicked: %v", r)
+		}
+	}()
+
+	response, err := referenceHandler.Handle(ctx, request)
+	if err != nil {
+		log.Printf("ERROR: Handler returned error: %v", err)
+		return utils.CreateErrorResponse(500, "Internal server error"), nil
+	}
+
+	return response, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
@@ -14,6 +13,8 @@ import (
 type TemplateProvider interface {
 	GetPromptTemplate() string
 	GetPromptTemplateForQuestion(question string) string
+	GetTemplateNameForQuestion(question string) string
+	GetComplexityForQuestion(question string) string
 }
 
 type BedrockProvider struct {
@@ -30,46 +31,80 @@ func NewBedrockProvider(bedrockClient aws.BedrockClient, templateProvider Templa
 	}
 }
 
-func (b *BedrockProvider) GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (string, error) {
-	template := b.templateProvider.GetPromptTemplateForQuestion(request.Question)
-	systemPrompt := strings.ReplaceAll(template, "{game}", request.GameName)
-	userContent := fmt.Sprintf("%s\n\nGame Context:\n%s\n\nQuestion: %s", systemPrompt, request.Knowledge, request.Question)
+func (b *BedrockProvider) GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error) {
+	bedrockRequest := b.buildRequest(request)
 
-	log.Printf("Using Bedrock model ID: %s for game: %s", b.bedrockClient.GetModelID(), request.GameName)
-	log.Printf("Request context: Knowledge length=%d, Question length=%d", len(request.Knowledge), len(request.Question))
+	response, err := b.bedrockClient.Converse(ctx, bedrockRequest)
+	if err != nil {
+		log.Printf("ERROR: Bedrock Converse failed: %v", err)
+		return nil, fmt.Errorf("failed to invoke model: %w", err)
+	}
+
+	log.Printf("Bedrock Converse succeeded with stop reason: %s, extracting response...", response.StopReason)
+	answer, err := b.extractTextFromResponse(response)
+	if err != nil {
+		log.Printf("ERROR: Failed to extract text from response: %v", err)
+		return nil, err
+	}
 
-	return b.generateAnswerWithClaude(ctx, userContent)
+	log.Printf("Successfully extracted answer with length: %d", len(answer))
+	return b.createAnswerResponse(request, answer), nil
 }
 
-func (b *BedrockProvider) generateAnswerWithClaude(ctx context.Context, userContent string) (string, error) {
-	bedrockRequest := &aws.BedrockRequest{
-		AnthropicVersion: "bedrock-2023-05-31",
-		Messages: []aws.BedrockMessage{
-			{
-				Role:    "user",
-				Content: userContent,
-			},
-		},
-		MaxTokens:   b.config.AnswerMaxTokens,
-		Temperature: b.config.AnswerTemperature,
-		TopP:        b.config.AnswerTopP,
-	}
+// StreamAnswer calls onText with each piece of the answer as it is generated and returns the full answer
+func (b *BedrockProvider) StreamAnswer(ctx context.Context, request *types.AnswerRequest, onText func(text string) error) (*types.AnswerResponse, error) {
+	bedrockRequest := b.buildRequest(request)
 
-	response, err := b.bedrockClient.InvokeModel(ctx, bedrockRequest)
+	response, err := b.bedrockClient.ConverseStream(ctx, bedrockRequest, onText)
 	if err != nil {
-		log.Printf("ERROR: Bedrock InvokeModel failed: %v", err)
-		return "", fmt.Errorf("failed to invoke model: %w", err)
+		log.Printf("ERROR: Bedrock ConverseStream failed: %v", err)
+		return nil, fmt.Errorf("failed to invoke model: %w", err)
 	}
 
-	log.Printf("Bedrock InvokeModel succeeded, extracting response...")
+	log.Printf("Bedrock ConverseStream finished with stop reason: %s", response.StopReason)
 	answer, err := b.extractTextFromResponse(response)
 	if err != nil {
 		log.Printf("ERROR: Failed to extract text from response: %v", err)
-		return "", err
+		return nil, err
 	}
 
-	log.Printf("Successfully extracted answer with length: %d", len(answer))
-	return answer, nil
+	log.Printf("Successfully streamed answer with length: %d", len(answer))
+	return b.createAnswerResponse(request, answer), nil
+}
+
+func (b *BedrockProvider) buildRequest(request *types.AnswerRequest) *aws.BedrockRequest {
+	systemPrompt, userContent := buildPrompt(b.templateProvider, request)
+
+	log.Printf("Using Bedrock model ID: %s for game: %s", b.bedrockClient.GetModelID(), request.GameName)
+	log.Printf("Request context: Knowledge length=%d, Question length=%d", len(request.Knowledge), len(request.Question))
+
+	// Earlier turns come first so the model can resolve follow-up questions
+	var messages []aws.BedrockMessage
+	for _, turn := range request.History {
+		messages = append(messages,
+			aws.BedrockMessage{Role: "user", Content: turn.Question},
+			aws.BedrockMessage{Role: "assistant", Content: turn.Answer},
+		)
+	}
+	messages = append(messages, aws.BedrockMessage{Role: "user", Content: userContent})
+
+	temperature, topP := b.config.AnswerTemperature, b.config.AnswerTopP
+	return &aws.BedrockRequest{
+		System:      systemPrompt,
+		Messages:    messages,
+		MaxTokens:   b.config.AnswerMaxTokens,
+		Temperature: &temperature,
+		TopP:        &topP,
+	}
+}
+
+func (b *BedrockProvider) createAnswerResponse(request *types.AnswerRequest, answer string) *types.AnswerResponse {
+	return &types.AnswerResponse{
+		Answer:         answer,
+		ModelID:        b.bedrockClient.GetModelID(),
+		PromptTemplate: b.templateProvider.GetTemplateNameForQuestion(request.Question),
+		Complexity:     b.templateProvider.GetComplexityForQuestion(request.Question),
+	}
 }
 
 func (b *BedrockProvider) extractTextFromResponse(response *aws.BedrockResponse) (string, error) {
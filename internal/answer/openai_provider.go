@@ -0,0 +1,69 @@
+package answer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/openai"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+type OpenAIProvider struct {
+	client           openai.Client
+	templateProvider TemplateProvider
+	config           *config.OpenAI
+}
+
+func NewOpenAIProvider(client openai.Client, templateProvider TemplateProvider, config *config.OpenAI) *OpenAIProvider {
+	return &OpenAIProvider{
+		client:           client,
+		templateProvider: templateProvider,
+		config:           config,
+	}
+}
+
+func (o *OpenAIProvider) GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error) {
+	systemPrompt, userContent := buildPrompt(o.templateProvider, request)
+
+	log.Printf("Using OpenAI compatible model: %s for game: %s", o.client.GetModelID(), request.GameName)
+	log.Printf("Request context: Knowledge length=%d, Question length=%d", len(request.Knowledge), len(request.Question))
+
+	messages := []openai.ChatMessage{{Role: "system", Content: systemPrompt}}
+	for _, turn := range request.History {
+		messages = append(messages,
+			openai.ChatMessage{Role: "user", Content: turn.Question},
+			openai.ChatMessage{Role: "assistant", Content: turn.Answer},
+		)
+	}
+	messages = append(messages, openai.ChatMessage{Role: "user", Content: userContent})
+
+	temperature, topP := o.config.AnswerTemperature, o.config.AnswerTopP
+	chatRequest := &openai.ChatRequest{
+		Model:       o.client.GetModelID(),
+		Messages:    messages,
+		MaxTokens:   o.config.AnswerMaxTokens,
+		Temperature: &temperature,
+		TopP:        &topP,
+	}
+
+	response, err := o.client.CreateChatCompletion(ctx, chatRequest)
+	if err != nil {
+		log.Printf("ERROR: Chat completion failed: %v", err)
+		return nil, fmt.Errorf("failed to invoke model: %w", err)
+	}
+
+	if len(response.Choices) == 0 || response.Choices[0].Message.Content == "" {
+		return nil, fmt.Errorf("no text content found in response")
+	}
+
+	answer := response.Choices[0].Message.Content
+	log.Printf("Successfully extracted answer with length: %d", len(answer))
+	return &types.AnswerResponse{
+		Answer:         answer,
+		ModelID:        o.client.GetModelID(),
+		PromptTemplate: o.templateProvider.GetTemplateNameForQuestion(request.Question),
+		Complexity:     o.templateProvider.GetComplexityForQuestion(request.Question),
+	}, nil
+}
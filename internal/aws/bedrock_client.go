@@ -2,14 +2,15 @@ package aws
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awscfg "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 )
 
 type AWSBedrockClient struct {
@@ -38,29 +39,109 @@ func NewAWSBedrockClient(config *config.Bedrock) (*AWSBedrockClient, error) {
 	}, nil
 }
 
-func (b *AWSBedrockClient) InvokeModel(ctx context.Context, request *BedrockRequest) (*BedrockResponse, error) {
-	requestBody, err := json.Marshal(request)
+func (b *AWSBedrockClient) Converse(ctx context.Context, request *BedrockRequest) (*BedrockResponse, error) {
+	system, messages, inferenceConfig := b.buildConverseParts(request)
+
+	result, err := b.client.Converse(ctx, &bedrockruntime.ConverseInput{
+		ModelId:         aws.String(b.modelID),
+		System:          system,
+		Messages:        messages,
+		InferenceConfig: inferenceConfig,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to invoke model: %w", err)
 	}
 
-	input := &bedrockruntime.InvokeModelInput{
-		ModelId:     aws.String(b.modelID),
-		ContentType: aws.String("application/json"),
-		Body:        requestBody,
+	output, ok := result.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected converse output type: %T", result.Output)
 	}
 
-	result, err := b.client.InvokeModel(ctx, input)
+	response := &BedrockResponse{StopReason: string(result.StopReason)}
+	for _, block := range output.Value.Content {
+		if text, ok := block.(*types.ContentBlockMemberText); ok {
+			response.Content = append(response.Content, BedrockContent{Type: "text", Text: text.Value})
+		}
+	}
+
+	return response, nil
+}
+
+// ConverseStream calls onText with each piece of text as the model generates it and
+// returns the complete response once the model has finished
+func (b *AWSBedrockClient) ConverseStream(ctx context.Context, request *BedrockRequest, onText func(text string) error) (*BedrockResponse, error) {
+	system, messages, inferenceConfig := b.buildConverseParts(request)
+
+	result, err := b.client.ConverseStream(ctx, &bedrockruntime.ConverseStreamInput{
+		ModelId:         aws.String(b.modelID),
+		System:          system,
+		Messages:        messages,
+		InferenceConfig: inferenceConfig,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to invoke model: %w", err)
+		return nil, fmt.Errorf("failed to invoke model with stream: %w", err)
+	}
+
+	stream := result.GetStream()
+	defer stream.Close()
+
+	var text strings.Builder
+	response := &BedrockResponse{}
+	for event := range stream.Events() {
+		switch e := event.(type) {
+		case *types.ConverseStreamOutputMemberContentBlockDelta:
+			delta, ok := e.Value.Delta.(*types.ContentBlockDeltaMemberText)
+			if !ok {
+				continue
+			}
+			text.WriteString(delta.Value)
+			if err := onText(delta.Value); err != nil {
+				return nil, fmt.Errorf("failed to handle streamed text: %w", err)
+			}
+		case *types.ConverseStreamOutputMemberMessageStop:
+			response.StopReason = string(e.Value.StopReason)
+		}
 	}
 
-	var response BedrockResponse
-	if err := json.Unmarshal(result.Body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read model stream: %w", err)
+	}
+
+	if text.Len() > 0 {
+		response.Content = []BedrockContent{{Type: "text", Text: text.String()}}
+	}
+
+	return response, nil
+}
+
+func (b *AWSBedrockClient) buildConverseParts(request *BedrockRequest) ([]types.SystemContentBlock, []types.Message, *types.InferenceConfiguration) {
+	var system []types.SystemContentBlock
+	if request.System != "" {
+		system = []types.SystemContentBlock{
+			&types.SystemContentBlockMemberText{Value: request.System},
+		}
+	}
+
+	messages := make([]types.Message, len(request.Messages))
+	for i, message := range request.Messages {
+		messages[i] = types.Message{
+			Role:    types.ConversationRole(message.Role),
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: message.Content}},
+		}
+	}
+
+	inferenceConfig := &types.InferenceConfiguration{}
+	if request.MaxTokens > 0 {
+		inferenceConfig.MaxTokens = aws.Int32(int32(request.MaxTokens))
+	}
+	if request.Temperature != nil {
+		inferenceConfig.Temperature = aws.Float32(float32(*request.Temperature))
+	}
+	if request.TopP != nil {
+		inferenceConfig.TopP = aws.Float32(float32(*request.TopP))
 	}
 
-	return &response, nil
+	return system, messages, inferenceConfig
 }
 
 func (b *AWSBedrockClient) InvokeEmbeddingModel(ctx context.Context, requestBody []byte) ([]byte, error) {
@@ -2,7 +2,11 @@ package aws
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	configPkg "github.com/PhilNel/go-boardgame-assistant/internal/config"
@@ -13,6 +17,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// ErrItemNotFound is returned by GetItem when no item exists for the key
+var ErrItemNotFound = errors.New("item not found")
+
+// ErrInvalidCursor is returned by QueryPage and ScanPage when the cursor was not created by them
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 type AWSDynamoDBClient struct {
 	client *dynamodb.Client
 }
@@ -59,7 +69,7 @@ func (d *AWSDynamoDBClient) GetItem(ctx context.Context, tableName string, key m
 	}
 
 	if output.Item == nil {
-		return fmt.Errorf("item not found")
+		return ErrItemNotFound
 	}
 
 	err = attributevalue.UnmarshalMap(output.Item, result)
@@ -136,6 +146,78 @@ func (d *AWSDynamoDBClient) BatchWriteItems(ctx context.Context, tableName strin
 	return nil
 }
 
+// BatchGetItems sends the keys in batches of the DynamoDB limit, concurrently, and retries
+// keys that DynamoDB returns as unprocessed
+func (d *AWSDynamoDBClient) BatchGetItems(ctx context.Context, tableName string, keys []map[string]types.AttributeValue, results interface{}) error {
+	const batchSize = 100 // DynamoDB batch get limit
+
+	var batches [][]map[string]types.AttributeValue
+	for start := 0; start < len(keys); start += batchSize {
+		batches = append(batches, keys[start:min(start+batchSize, len(keys))])
+	}
+
+	batchItems := make([][]map[string]types.AttributeValue, len(batches))
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+
+	for i, batch := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batchItems[i], errs[i] = d.batchGet(ctx, tableName, batch)
+		}()
+	}
+	wg.Wait()
+
+	var items []map[string]types.AttributeValue
+	for i, err := range errs {
+		if err != nil {
+			return err
+		}
+		items = append(items, batchItems[i]...)
+	}
+
+	if err := attributevalue.UnmarshalListOfMaps(items, results); err != nil {
+		return fmt.Errorf("failed to unmarshal results: %w", err)
+	}
+
+	return nil
+}
+
+func (d *AWSDynamoDBClient) batchGet(ctx context.Context, tableName string, keys []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	const maxAttempts = 5
+
+	var items []map[string]types.AttributeValue
+	requestItems := map[string]types.KeysAndAttributes{
+		tableName: {Keys: keys},
+	}
+
+	for attempt := 1; len(requestItems) > 0; attempt++ {
+		if attempt > maxAttempts {
+			return nil, fmt.Errorf("failed to batch get items: %d keys still unprocessed after %d attempts", len(requestItems[tableName].Keys), maxAttempts)
+		}
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("failed to batch get items: %w", ctx.Err())
+			case <-time.After(time.Duration(attempt*50) * time.Millisecond):
+			}
+		}
+
+		output, err := d.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch get items: %w", err)
+		}
+
+		items = append(items, output.Responses[tableName]...)
+		requestItems = output.UnprocessedKeys
+	}
+
+	return items, nil
+}
+
 func (d *AWSDynamoDBClient) UpdateItem(ctx context.Context, tableName string, key map[string]types.AttributeValue, updateExpression string, expressionValues map[string]types.AttributeValue) error {
 	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName:                 aws.String(tableName),
@@ -150,6 +232,151 @@ func (d *AWSDynamoDBClient) UpdateItem(ctx context.Context, tableName string, ke
 	return nil
 }
 
+func (d *AWSDynamoDBClient) DeleteItem(ctx context.Context, tableName string, key map[string]types.AttributeValue) error {
+	output, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:    aws.String(tableName),
+		Key:          key,
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+
+	if len(output.Attributes) == 0 {
+		return ErrItemNotFound
+	}
+
+	return nil
+}
+
+func (d *AWSDynamoDBClient) QueryPage(ctx context.Context, input *PageInput, results interface{}) (string, error) {
+	startKey, err := decodeCursor(input.Cursor)
+	if err != nil {
+		return "", err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(input.TableName),
+		KeyConditionExpression: aws.String(input.KeyCondition),
+		ExclusiveStartKey:      startKey,
+		ScanIndexForward:       aws.Bool(!input.Descending),
+	}
+	if input.IndexName != "" {
+		queryInput.IndexName = aws.String(input.IndexName)
+	}
+	if input.FilterExpression != "" {
+		queryInput.FilterExpression = aws.String(input.FilterExpression)
+	}
+	if input.Projection != "" {
+		queryInput.ProjectionExpression = aws.String(input.Projection)
+	}
+	// DynamoDB rejects empty expression maps
+	if len(input.ExpressionNames) > 0 {
+		queryInput.ExpressionAttributeNames = input.ExpressionNames
+	}
+	if len(input.ExpressionValues) > 0 {
+		queryInput.ExpressionAttributeValues = input.ExpressionValues
+	}
+	if input.Limit > 0 {
+		queryInput.Limit = aws.Int32(input.Limit)
+	}
+
+	output, err := d.client.Query(ctx, queryInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to query: %w", err)
+	}
+
+	if err := attributevalue.UnmarshalListOfMaps(output.Items, results); err != nil {
+		return "", fmt.Errorf("failed to unmarshal results: %w", err)
+	}
+
+	return encodeCursor(output.LastEvaluatedKey)
+}
+
+func (d *AWSDynamoDBClient) ScanPage(ctx context.Context, input *PageInput, results interface{}) (string, error) {
+	startKey, err := decodeCursor(input.Cursor)
+	if err != nil {
+		return "", err
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:         aws.String(input.TableName),
+		ExclusiveStartKey: startKey,
+	}
+	if input.IndexName != "" {
+		scanInput.IndexName = aws.String(input.IndexName)
+	}
+	if input.FilterExpression != "" {
+		scanInput.FilterExpression = aws.String(input.FilterExpression)
+	}
+	if input.Projection != "" {
+		scanInput.ProjectionExpression = aws.String(input.Projection)
+	}
+	// DynamoDB rejects empty expression maps
+	if len(input.ExpressionNames) > 0 {
+		scanInput.ExpressionAttributeNames = input.ExpressionNames
+	}
+	if len(input.ExpressionValues) > 0 {
+		scanInput.ExpressionAttributeValues = input.ExpressionValues
+	}
+	if input.Limit > 0 {
+		scanInput.Limit = aws.Int32(input.Limit)
+	}
+
+	output, err := d.client.Scan(ctx, scanInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan: %w", err)
+	}
+
+	if err := attributevalue.UnmarshalListOfMaps(output.Items, results); err != nil {
+		return "", fmt.Errorf("failed to unmarshal results: %w", err)
+	}
+
+	return encodeCursor(output.LastEvaluatedKey)
+}
+
+// encodeCursor turns a LastEvaluatedKey into an opaque string that is safe to put in a URL
+func encodeCursor(lastKey map[string]types.AttributeValue) (string, error) {
+	if len(lastKey) == 0 {
+		return "", nil
+	}
+
+	var key map[string]interface{}
+	if err := attributevalue.UnmarshalMap(lastKey, &key); err != nil {
+		return "", fmt.Errorf("failed to unmarshal last evaluated key: %w", err)
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var key map[string]interface{}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	startKey, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return startKey, nil
+}
+
 func GetCurrentTimestamp() int64 {
 	return time.Now().Unix()
 }
@@ -1,7 +1,9 @@
 package aws
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 
@@ -9,8 +11,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awscfg "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// ErrObjectNotFound is returned when the requested S3 key does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
 type AWSS3Client struct {
 	client *s3.Client
 	bucket string
@@ -40,6 +46,10 @@ func (s *AWSS3Client) GetObject(ctx context.Context, key string) ([]byte, error)
 
 	result, err := s.client.GetObject(ctx, input)
 	if err != nil {
+		var noSuchKey *s3Types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("failed to get object %s: %w", key, ErrObjectNotFound)
+		}
 		return nil, fmt.Errorf("failed to get object: %w", err)
 	}
 	defer result.Body.Close()
@@ -47,6 +57,21 @@ func (s *AWSS3Client) GetObject(ctx context.Context, key string) ([]byte, error)
 	return io.ReadAll(result.Body)
 }
 
+func (s *AWSS3Client) PutObject(ctx context.Context, key string, body []byte) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	}
+
+	_, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+
+	return nil
+}
+
 func (s *AWSS3Client) ListObjectsWithPrefix(ctx context.Context, prefix string) ([]string, error) {
 	input := &s3.ListObjectsV2Input{
 		Bucket: aws.String(s.bucket),
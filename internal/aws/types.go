@@ -11,39 +11,71 @@ type DynamoDBClient interface {
 	GetItem(ctx context.Context, tableName string, key map[string]types.AttributeValue, result interface{}) error
 	Query(ctx context.Context, tableName string, indexName *string, keyCondition string, expressionAttributeValues map[string]types.AttributeValue, result interface{}) error
 	BatchWriteItems(ctx context.Context, tableName string, items []interface{}) error
+	// BatchGetItems reads the items for the keys into results, a pointer to a slice. Keys
+	// without an item are left out, and items are not in the order of the keys.
+	BatchGetItems(ctx context.Context, tableName string, keys []map[string]types.AttributeValue, results interface{}) error
 	UpdateItem(ctx context.Context, tableName string, key map[string]types.AttributeValue, updateExpression string, expressionValues map[string]types.AttributeValue) error
+	// DeleteItem returns ErrItemNotFound when no item exists for the key
+	DeleteItem(ctx context.Context, tableName string, key map[string]types.AttributeValue) error
+	// QueryPage and ScanPage read one page of results and return the cursor of the
+	// next page, which is empty once there are no more results
+	QueryPage(ctx context.Context, input *PageInput, results interface{}) (string, error)
+	ScanPage(ctx context.Context, input *PageInput, results interface{}) (string, error)
+}
+
+// PageInput describes one page of a query or scan. KeyCondition is only used by queries.
+// DynamoDB applies Limit before FilterExpression, so filtered pages can hold fewer items.
+type PageInput struct {
+	TableName        string
+	IndexName        string
+	KeyCondition     string
+	FilterExpression string
+	// Projection lists the attributes to read, empty reads every attribute
+	Projection       string
+	ExpressionNames  map[string]string
+	ExpressionValues map[string]types.AttributeValue
+	Limit            int32
+	// Cursor is the value returned for the previous page, empty for the first page
+	Cursor string
+	// Descending returns query results in descending sort key order
+	Descending bool
 }
 
 type S3Client interface {
 	ListObjectsWithPrefix(ctx context.Context, prefix string) ([]string, error)
 	GetObject(ctx context.Context, key string) ([]byte, error)
+	PutObject(ctx context.Context, key string, body []byte) error
 }
 
 type BedrockClient interface {
-	InvokeModel(ctx context.Context, request *BedrockRequest) (*BedrockResponse, error)
+	Converse(ctx context.Context, request *BedrockRequest) (*BedrockResponse, error)
+	ConverseStream(ctx context.Context, request *BedrockRequest, onText func(text string) error) (*BedrockResponse, error)
 	InvokeEmbeddingModel(ctx context.Context, requestBody []byte) ([]byte, error)
 	GetModelID() string
 	GetEmbeddingModelID() string
 }
 
+// BedrockRequest is sent with the Converse API, so it works with any Bedrock chat model.
+// A zero MaxTokens and nil Temperature or TopP leave the model defaults in place.
 type BedrockRequest struct {
-	AnthropicVersion string           `json:"anthropic_version"`
-	Messages         []BedrockMessage `json:"messages"`
-	MaxTokens        int              `json:"max_tokens,omitempty"`
-	Temperature      float64          `json:"temperature,omitempty"`
-	TopP             float64          `json:"top_p,omitempty"`
+	System      string
+	Messages    []BedrockMessage
+	MaxTokens   int
+	Temperature *float64
+	TopP        *float64
 }
 
 type BedrockMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string
+	Content string
 }
 
 type BedrockResponse struct {
-	Content []BedrockContent `json:"content"`
+	Content    []BedrockContent
+	StopReason string
 }
 
 type BedrockContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type string
+	Text string
 }
@@ -10,6 +10,7 @@ type Config struct {
 	Log      *Log
 	S3       *S3
 	Bedrock  *Bedrock
+	OpenAI   *OpenAI
 	DynamoDB *DynamoDB
 	RAG      *RAG
 	System   *System
@@ -17,17 +18,36 @@ type Config struct {
 
 type System struct {
 	KnowledgeProvider string `long:"knowledge_provider" env:"KNOWLEDGE_PROVIDER" description:"Knowledge provider to use (s3 or vector)" default:"s3"`
+	ModelProvider     string `long:"model_provider" env:"MODEL_PROVIDER" description:"Provider for answers and embeddings (bedrock or openai)" default:"bedrock"`
+	AdminAPIKey       string `long:"admin_api_key" env:"ADMIN_API_KEY" description:"Key required in the x-admin-key header for admin routes, leave empty to disable them"`
+	ResponseMode      string `long:"response_mode" env:"RESPONSE_MODE" description:"How the question handler returns answers (buffered through API Gateway or streaming through a function URL)" default:"buffered"`
 }
 
 type Bedrock struct {
 	ModelID           string  `long:"bedrock_model_id" env:"BEDROCK_MODEL_ID" description:"Bedrock model ID to use" default:"anthropic.claude-3-haiku-20240307-v1:0"`
-	EmbeddingModelID  string  `long:"bedrock_embedding_model_id" env:"BEDROCK_EMBEDDING_MODEL_ID" description:"Bedrock embedding model ID" default:"amazon.titan-embed-text-v2:0"`
+	EmbeddingModelID  string  `long:"bedrock_embedding_model_id" env:"BEDROCK_EMBEDDING_MODEL_ID" description:"Bedrock embedding model ID (amazon.titan-embed-* or cohere.embed-*)" default:"amazon.titan-embed-text-v2:0"`
+	EmbeddingDims     int     `long:"bedrock_embedding_dimensions" env:"BEDROCK_EMBEDDING_DIMENSIONS" description:"Number of dimensions requested from the embedding model, ignored by fixed size models such as Cohere v3" default:"256"`
+	EmbeddingWorkers  int     `long:"bedrock_embedding_workers" env:"BEDROCK_EMBEDDING_WORKERS" description:"Maximum concurrent embedding requests when embedding a batch" default:"4"`
 	Region            string  `long:"aws_region_bedrock" env:"AWS_REGION" description:"AWS region to use" default:"eu-west-1"`
 	AnswerMaxTokens   int     `long:"bedrock_max_tokens" env:"BEDROCK_ANSWER_MAX_TOKENS" description:"Maximum tokens to include in the answer" default:"1500"`
 	AnswerTemperature float64 `long:"bedrock_temperature" env:"BEDROCK_ANSWER_TEMPERATURE" description:"Temperature for the Bedrock model answers" default:"0.1"`
 	AnswerTopP        float64 `long:"bedrock_top_p" env:"BEDROCK_ANSWER_TOP_P" description:"TopP for the Bedrock model answers" default:"0.9"`
 }
 
+// OpenAI configures any server implementing the OpenAI API, such as llama.cpp server, vLLM or Ollama
+type OpenAI struct {
+	BaseURL           string  `long:"openai_base_url" env:"OPENAI_BASE_URL" description:"Base URL of the OpenAI compatible API" default:"http://localhost:8080/v1"`
+	APIKey            string  `long:"openai_api_key" env:"OPENAI_API_KEY" description:"API key sent as a bearer token, leave empty for servers without authentication"`
+	ModelID           string  `long:"openai_model_id" env:"OPENAI_MODEL_ID" description:"Chat model used for answers"`
+	EmbeddingModelID  string  `long:"openai_embedding_model_id" env:"OPENAI_EMBEDDING_MODEL_ID" description:"Embedding model used for knowledge chunks and questions"`
+	EmbeddingDims     int     `long:"openai_embedding_dimensions" env:"OPENAI_EMBEDDING_DIMENSIONS" description:"Number of dimensions returned by the embedding model" default:"768"`
+	EmbeddingBatch    int     `long:"openai_embedding_batch_size" env:"OPENAI_EMBEDDING_BATCH_SIZE" description:"Maximum texts sent in one embeddings request" default:"32"`
+	TimeoutSeconds    int     `long:"openai_timeout_seconds" env:"OPENAI_TIMEOUT_SECONDS" description:"Timeout for each request" default:"120"`
+	AnswerMaxTokens   int     `long:"openai_max_tokens" env:"OPENAI_ANSWER_MAX_TOKENS" description:"Maximum tokens to include in the answer" default:"1500"`
+	AnswerTemperature float64 `long:"openai_temperature" env:"OPENAI_ANSWER_TEMPERATURE" description:"Temperature for the model answers" default:"0.1"`
+	AnswerTopP        float64 `long:"openai_top_p" env:"OPENAI_ANSWER_TOP_P" description:"TopP for the model answers" default:"0.9"`
+}
+
 type Log struct {
 	Level string `long:"log_level" env:"LOG_LEVEL" description:"Log level (debug, info, warn, error)" default:"info"`
 }
@@ -41,18 +61,36 @@ type DynamoDB struct {
 	KnowledgeTable  string `long:"knowledge_table" env:"KNOWLEDGE_TABLE_NAME" description:"DynamoDB table for knowledge chunks"`
 	JobsTable       string `long:"jobs_table" env:"JOBS_TABLE_NAME" description:"DynamoDB table for processing jobs"`
 	FeedbackTable   string `long:"feedback_table" env:"FEEDBACK_TABLE_NAME" description:"DynamoDB table for feedback submissions"`
+	FeedbackIndex   string `long:"feedback_game_index" env:"FEEDBACK_GAME_INDEX_NAME" description:"Feedback table index with game_name as partition key and created_at as sort key" default:"game_name-created_at-index"`
 	ReferencesTable string `long:"references_table" env:"REFERENCES_TABLE_NAME" description:"DynamoDB table for game references"`
+	AnswersTable    string `long:"answers_table" env:"ANSWERS_TABLE_NAME" description:"DynamoDB table for answered messages that feedback refers to"`
+	EmbeddingCache  string `long:"embedding_cache_table" env:"EMBEDDING_CACHE_TABLE_NAME" description:"DynamoDB table for cached embeddings, leave empty to disable caching"`
+	Conversations   string `long:"conversations_table" env:"CONVERSATIONS_TABLE_NAME" description:"DynamoDB table for conversation history, leave empty to answer every question on its own"`
 	Region          string `long:"aws_region_dynamodb" env:"AWS_REGION" description:"AWS region to use" default:"eu-west-1"`
 }
 
 type RAG struct {
-	MinSimilarity  float64 `long:"rag_min_similarity" env:"RAG_MIN_SIMILARITY" description:"Minimum similarity threshold for vector search" default:"0.65"`
-	MaxTokens      int     `long:"rag_max_tokens" env:"RAG_MAX_TOKENS" description:"Maximum tokens to include in context" default:"2000"`
-	TopK           int     `long:"rag_top_k" env:"RAG_TOP_K" description:"Maximum number of chunks to retrieve" default:"10"`
-	CacheTTLHours  int     `long:"cache_ttl_hours" env:"CACHE_TTL_HOURS" description:"Cache TTL in hours" default:"24"`
-	MaxChunkTokens int     `long:"max_chunk_tokens" env:"MAX_CHUNK_TOKENS" description:"Maximum tokens per chunk" default:"500"`
-	VectorWeight   float64 `long:"rag_vector_weight" env:"RAG_VECTOR_WEIGHT" description:"Weight for vector search in hybrid mode" default:"0.7"`
-	KeywordWeight  float64 `long:"rag_keyword_weight" env:"RAG_KEYWORD_WEIGHT" description:"Weight for keyword search in hybrid mode" default:"0.3"`
+	MinSimilarity     float64  `long:"rag_min_similarity" env:"RAG_MIN_SIMILARITY" description:"Minimum similarity threshold for vector search" default:"0.65"`
+	MaxTokens         int      `long:"rag_max_tokens" env:"RAG_MAX_TOKENS" description:"Maximum tokens to include in context" default:"2000"`
+	TopK              int      `long:"rag_top_k" env:"RAG_TOP_K" description:"Maximum number of chunks to retrieve" default:"10"`
+	CacheTTLHours     int      `long:"cache_ttl_hours" env:"CACHE_TTL_HOURS" description:"Cache TTL in hours" default:"24"`
+	MaxChunkTokens    int      `long:"max_chunk_tokens" env:"MAX_CHUNK_TOKENS" description:"Maximum tokens per chunk" default:"500"`
+	VectorWeight      float64  `long:"rag_vector_weight" env:"RAG_VECTOR_WEIGHT" description:"Weight for vector search in hybrid mode" default:"0.7"`
+	KeywordWeight     float64  `long:"rag_keyword_weight" env:"RAG_KEYWORD_WEIGHT" description:"Weight for keyword search in hybrid mode" default:"0.3"`
+	QueryRewriter     string   `long:"rag_query_rewriter" env:"RAG_QUERY_REWRITER" description:"Query rewriting before retrieval (none, bedrock or synonyms)" default:"none"`
+	MaxRewrites       int      `long:"rag_max_rewrites" env:"RAG_MAX_REWRITES" description:"Maximum number of rewritten queries searched alongside the original" default:"3"`
+	KeywordShortTerms []string `long:"rag_keyword_short_terms" env:"RAG_KEYWORD_SHORT_TERMS" env-delim:"," description:"Short game terms kept by the keyword tokenizer" default:"ap" default:"hp" default:"xp" default:"vp"`
+	IndexMinChunks    int      `long:"rag_index_min_chunks" env:"RAG_INDEX_MIN_CHUNKS" description:"Minimum chunks in a game, one per rule file, before an approximate nearest neighbour index is used" default:"50"`
+	IndexLists        int      `long:"rag_index_lists" env:"RAG_INDEX_LISTS" description:"Number of clusters in the vector index, 0 uses the square root of the chunk count" default:"0"`
+	IndexProbes       int      `long:"rag_index_probes" env:"RAG_INDEX_PROBES" description:"Number of closest clusters searched per query" default:"3"`
+	EmbeddingFormat   string   `long:"rag_embedding_format" env:"RAG_EMBEDDING_FORMAT" description:"Storage format for chunk embeddings (float32 or int8)" default:"float32"`
+	ConversationTurns int      `long:"rag_conversation_turns" env:"RAG_CONVERSATION_TURNS" description:"Number of earlier turns used as context for follow-up questions" default:"3"`
+	ConversationTTL   int      `long:"conversation_ttl_hours" env:"CONVERSATION_TTL_HOURS" description:"Hours after the last question before a conversation expires" default:"24"`
+	Grounding         string   `long:"rag_grounding_verifier" env:"RAG_GROUNDING_VERIFIER" description:"Checks answers against the retrieved rules (none, heuristic or bedrock)" default:"none"`
+	GroundingAction   string   `long:"rag_grounding_action" env:"RAG_GROUNDING_ACTION" description:"What to do with unsupported sentences (flag or strip)" default:"flag"`
+	GroundingSupport  float64  `long:"rag_grounding_min_support" env:"RAG_GROUNDING_MIN_SUPPORT" description:"Fraction of a sentence's keywords that must appear in a chunk for the heuristic verifier" default:"0.6"`
+	CitationAction    string   `long:"rag_invalid_citation_action" env:"RAG_INVALID_CITATION_ACTION" description:"What to do with citations of references that were not retrieved (drop or flag)" default:"drop"`
+	UndefinedRefs     string   `long:"rag_undefined_references" env:"RAG_UNDEFINED_REFERENCES" description:"What the knowledge processor does when rule files cite undefined references (warn or fail)" default:"warn"`
 }
 
 func Load() (*Config, error) {
@@ -64,3 +102,19 @@ func Load() (*Config, error) {
 
 	return opts, nil
 }
+
+// LoadWithOptions also parses the options of a command line tool into options,
+// which must be a pointer to a struct with go-flags tags
+func LoadWithOptions(options interface{}) (*Config, error) {
+	opts := &Config{}
+	parser := flags.NewParser(opts, flags.Default)
+	if _, err := parser.AddGroup("Command Options", "", options); err != nil {
+		return nil, fmt.Errorf("failed to add command options: %w", err)
+	}
+
+	if _, err := parser.Parse(); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,64 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoDBRepository struct {
+	dynamoDB           aws.DynamoDBClient
+	conversationsTable string
+	ttl                time.Duration
+}
+
+// Conversations are saved with an expires_at attribute ttl from the last update,
+// which the table's TTL setting should use to delete old conversations
+func NewDynamoDBRepository(dynamoClient aws.DynamoDBClient, conversationsTable string, ttl time.Duration) *DynamoDBRepository {
+	return &DynamoDBRepository{
+		dynamoDB:           dynamoClient,
+		conversationsTable: conversationsTable,
+		ttl:                ttl,
+	}
+}
+
+func (r *DynamoDBRepository) GetConversation(ctx context.Context, conversationID string) (*Conversation, error) {
+	key := map[string]dynamoTypes.AttributeValue{
+		"conversation_id": &dynamoTypes.AttributeValueMemberS{Value: conversationID},
+	}
+
+	var conversation Conversation
+	err := r.dynamoDB.GetItem(ctx, r.conversationsTable, key, &conversation)
+	if errors.Is(err, aws.ErrItemNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	// DynamoDB deletes expired items lazily, so they can still be returned for a while
+	if conversation.ExpiresAt < time.Now().Unix() {
+		return nil, nil
+	}
+
+	return &conversation, nil
+}
+
+func (r *DynamoDBRepository) SaveConversation(ctx context.Context, conversation *Conversation) error {
+	now := time.Now()
+	if conversation.CreatedAt == 0 {
+		conversation.CreatedAt = now.Unix()
+	}
+	conversation.UpdatedAt = now.Unix()
+	conversation.ExpiresAt = now.Add(r.ttl).Unix()
+
+	if err := r.dynamoDB.PutItem(ctx, r.conversationsTable, conversation); err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	return nil
+}
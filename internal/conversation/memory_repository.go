@@ -0,0 +1,62 @@
+package conversation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+// MemoryRepository keeps conversations in memory, for tests and local runs
+type MemoryRepository struct {
+	mu            sync.Mutex
+	conversations map[string]*Conversation
+	ttl           time.Duration
+}
+
+func NewMemoryRepository(ttl time.Duration) *MemoryRepository {
+	return &MemoryRepository{
+		conversations: make(map[string]*Conversation),
+		ttl:           ttl,
+	}
+}
+
+func (r *MemoryRepository) GetConversation(ctx context.Context, conversationID string) (*Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conversation, exists := r.conversations[conversationID]
+	if !exists {
+		return nil, nil
+	}
+
+	if conversation.ExpiresAt < time.Now().Unix() {
+		delete(r.conversations, conversationID)
+		return nil, nil
+	}
+
+	return copyConversation(conversation), nil
+}
+
+func (r *MemoryRepository) SaveConversation(ctx context.Context, conversation *Conversation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if conversation.CreatedAt == 0 {
+		conversation.CreatedAt = now.Unix()
+	}
+	conversation.UpdatedAt = now.Unix()
+	conversation.ExpiresAt = now.Add(r.ttl).Unix()
+
+	r.conversations[conversation.ConversationID] = copyConversation(conversation)
+	return nil
+}
+
+// copyConversation stops callers from changing stored turns, as they could with a DynamoDB item
+func copyConversation(conversation *Conversation) *Conversation {
+	copied := *conversation
+	copied.Turns = append([]types.ConversationTurn(nil), conversation.Turns...)
+	return &copied
+}
@@ -0,0 +1,34 @@
+package conversation
+
+import (
+	"context"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+type Conversation struct {
+	ConversationID string                   `dynamodbav:"conversation_id"`
+	GameName       string                   `dynamodbav:"game_name"`
+	Turns          []types.ConversationTurn `dynamodbav:"turns"`
+	CreatedAt      int64                    `dynamodbav:"created_at"`
+	UpdatedAt      int64                    `dynamodbav:"updated_at"`
+	// ExpiresAt is the DynamoDB TTL attribute
+	ExpiresAt int64 `dynamodbav:"expires_at"`
+}
+
+type Repository interface {
+	// GetConversation returns nil when the conversation does not exist or has expired
+	GetConversation(ctx context.Context, conversationID string) (*Conversation, error)
+	SaveConversation(ctx context.Context, conversation *Conversation) error
+}
+
+// RecentTurns returns up to the last maxTurns turns of the conversation
+func (c *Conversation) RecentTurns(maxTurns int) []types.ConversationTurn {
+	if c == nil || maxTurns <= 0 {
+		return nil
+	}
+	if len(c.Turns) <= maxTurns {
+		return c.Turns
+	}
+	return c.Turns[len(c.Turns)-maxTurns:]
+}
@@ -2,30 +2,107 @@ package embedding
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log"
+	"sync"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
 )
 
 type BedrockCreator struct {
 	bedrockClient aws.BedrockClient
+	config        *config.Bedrock
+	family        ModelFamily
+	dimensions    int
 }
 
-func NewBedrockCreator(bedrockClient aws.BedrockClient) *BedrockCreator {
+func NewBedrockCreator(bedrockClient aws.BedrockClient, config *config.Bedrock) (*BedrockCreator, error) {
+	family, err := GetModelFamily(bedrockClient.GetEmbeddingModelID())
+	if err != nil {
+		return nil, err
+	}
+
+	modelID := bedrockClient.GetEmbeddingModelID()
+	dimensions := config.EmbeddingDims
+	if fixed := family.FixedDimensions(modelID); fixed > 0 && fixed != dimensions {
+		log.Printf("WARNING: Embedding model %s always returns %d dimensions, ignoring the configured %d",
+			modelID, fixed, dimensions)
+		dimensions = fixed
+	}
+
+	log.Printf("Initializing Bedrock embeddings with model: %s, dimensions: %d, batch size: %d",
+		modelID, dimensions, family.MaxBatchSize())
+
 	return &BedrockCreator{
 		bedrockClient: bedrockClient,
+		config:        config,
+		family:        family,
+		dimensions:    dimensions,
+	}, nil
+}
+
+func (b *BedrockCreator) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := b.embed(ctx, []string{text}, InputTypeQuery)
+	if err != nil {
+		return nil, err
 	}
+	return embeddings[0], nil
 }
 
-func (b *BedrockCreator) CreateEmbedding(ctx context.Context, text string) ([]float64, error) {
-	request := &TitanRequest{
-		InputText:  text,
-		Dimensions: 256,
-		Normalize:  true,
+// CreateEmbeddings splits the texts into the largest batches the model accepts and
+// sends the batches concurrently. Results are returned in the same order as the texts.
+func (b *BedrockCreator) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := b.family.MaxBatchSize()
+	embeddings := make([][]float32, len(texts))
+
+	var batchStarts []int
+	for start := 0; start < len(texts); start += batchSize {
+		batchStarts = append(batchStarts, start)
+	}
+	errs := make([]error, len(batchStarts))
+
+	workers := make(chan struct{}, max(b.config.EmbeddingWorkers, 1))
+	var wg sync.WaitGroup
+
+	for i, start := range batchStarts {
+		wg.Add(1)
+		workers <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			end := min(start+batchSize, len(texts))
+			batch, err := b.embed(ctx, texts[start:end], InputTypeDocument)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to embed texts %d-%d of %d: %w", start+1, end, len(texts), err)
+				return
+			}
+			copy(embeddings[start:end], batch)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	requestBody, err := json.Marshal(request)
+	return embeddings, nil
+}
+
+func (b *BedrockCreator) GetModelID() string {
+	return b.bedrockClient.GetEmbeddingModelID()
+}
+
+func (b *BedrockCreator) GetDimensions() int {
+	return b.dimensions
+}
+
+func (b *BedrockCreator) embed(ctx context.Context, texts []string, inputType InputType) ([][]float32, error) {
+	requestBody, err := b.family.BuildRequest(texts, inputType, b.dimensions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
 	}
@@ -35,10 +112,22 @@ func (b *BedrockCreator) CreateEmbedding(ctx context.Context, text string) ([]fl
 		return nil, fmt.Errorf("failed to invoke embedding model: %w", err)
 	}
 
-	var embeddingResponse TitanResponse
-	if err := json.Unmarshal(response, &embeddingResponse); err != nil {
+	embeddings, err := b.family.ParseResponse(response)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal embedding response: %w", err)
 	}
 
-	return embeddingResponse.Embedding, nil
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding model %s returned %d embeddings for %d texts",
+			b.GetModelID(), len(embeddings), len(texts))
+	}
+
+	for _, embedding := range embeddings {
+		if len(embedding) != b.dimensions {
+			return nil, fmt.Errorf("embedding model %s returned %d dimensions, expected %d",
+				b.GetModelID(), len(embedding), b.dimensions)
+		}
+	}
+
+	return embeddings, nil
 }
@@ -0,0 +1,93 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+)
+
+// CachedCreator wraps a Creator and reuses embeddings of text that was embedded
+// before with the same model and dimensions, so re-ingesting unchanged rule files
+// does not call the embedding model again
+type CachedCreator struct {
+	creator Creator
+	cache   Cache
+}
+
+func NewCachedCreator(creator Creator, cache Cache) *CachedCreator {
+	return &CachedCreator{
+		creator: creator,
+		cache:   cache,
+	}
+}
+
+// CreateEmbedding embeds a search query, which is not cached since queries rarely repeat
+// and are embedded differently to documents by some models
+func (c *CachedCreator) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return c.creator.CreateEmbedding(ctx, text)
+}
+
+func (c *CachedCreator) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = c.cacheKey(text)
+	}
+
+	cached, err := c.cache.GetEmbeddings(ctx, keys)
+	if err != nil {
+		log.Printf("WARNING: Failed to read embedding cache: %v", err)
+	}
+
+	var missingIndexes []int
+	var missingTexts []string
+	for i, text := range texts {
+		embedding, exists := cached[keys[i]]
+		if !exists {
+			missingIndexes = append(missingIndexes, i)
+			missingTexts = append(missingTexts, text)
+			continue
+		}
+
+		embeddings[i] = embedding
+	}
+
+	log.Printf("Embedding cache hits: %d/%d", len(texts)-len(missingTexts), len(texts))
+
+	if len(missingTexts) == 0 {
+		return embeddings, nil
+	}
+
+	created, err := c.creator.CreateEmbeddings(ctx, missingTexts)
+	if err != nil {
+		return nil, err
+	}
+
+	toCache := make(map[string][]float32, len(missingIndexes))
+	for j, i := range missingIndexes {
+		embeddings[i] = created[j]
+		toCache[keys[i]] = created[j]
+	}
+
+	// Caching is best effort, a failed write only costs a Bedrock call next time
+	if err := c.cache.SaveEmbeddings(ctx, c.creator.GetModelID(), toCache); err != nil {
+		log.Printf("WARNING: Failed to write embedding cache: %v", err)
+	}
+
+	return embeddings, nil
+}
+
+func (c *CachedCreator) GetModelID() string {
+	return c.creator.GetModelID()
+}
+
+func (c *CachedCreator) GetDimensions() int {
+	return c.creator.GetDimensions()
+}
+
+func (c *CachedCreator) cacheKey(text string) string {
+	combined := fmt.Sprintf("%s:%d:%s", c.creator.GetModelID(), c.creator.GetDimensions(), text)
+	hash := sha256.Sum256([]byte(combined))
+	return fmt.Sprintf("%x", hash)
+}
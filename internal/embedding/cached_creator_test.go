@@ -0,0 +1,112 @@
+package embedding
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type fakeCreator struct {
+	modelID  string
+	requests [][]string
+}
+
+func (f *fakeCreator) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := f.CreateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (f *fakeCreator) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	f.requests = append(f.requests, texts)
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = []float32{float32(len(text)), 1}
+	}
+	return embeddings, nil
+}
+
+func (f *fakeCreator) GetModelID() string { return f.modelID }
+func (f *fakeCreator) GetDimensions() int { return 2 }
+
+type memoryCache struct {
+	embeddings map[string][]float32
+	// calls counts the cache reads and writes, which are each one batch
+	calls int
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{embeddings: make(map[string][]float32)}
+}
+
+func (m *memoryCache) GetEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error) {
+	m.calls++
+	embeddings := make(map[string][]float32)
+	for _, key := range keys {
+		if embedding, exists := m.embeddings[key]; exists {
+			embeddings[key] = embedding
+		}
+	}
+	return embeddings, nil
+}
+
+func (m *memoryCache) SaveEmbeddings(ctx context.Context, modelID string, embeddings map[string][]float32) error {
+	m.calls++
+	for key, embedding := range embeddings {
+		m.embeddings[key] = embedding
+	}
+	return nil
+}
+
+func TestCachedCreatorOnlyEmbedsUncachedText(t *testing.T) {
+	ctx := context.Background()
+	creator := &fakeCreator{modelID: "model-a"}
+	cache := newMemoryCache()
+	cached := NewCachedCreator(creator, cache)
+
+	first, err := cached.CreateEmbeddings(ctx, []string{"one", "three"})
+	if err != nil {
+		t.Fatalf("Failed to create embeddings: %v", err)
+	}
+
+	second, err := cached.CreateEmbeddings(ctx, []string{"three", "fives", "one"})
+	if err != nil {
+		t.Fatalf("Failed to create embeddings: %v", err)
+	}
+
+	expectedRequests := [][]string{{"one", "three"}, {"fives"}}
+	if !reflect.DeepEqual(creator.requests, expectedRequests) {
+		t.Errorf("Expected requests %q, got %q", expectedRequests, creator.requests)
+	}
+
+	expected := [][]float32{first[1], {5, 1}, first[0]}
+	if !reflect.DeepEqual(second, expected) {
+		t.Errorf("Expected embeddings %v in input order, got %v", expected, second)
+	}
+
+	// One batch read and one batch write for each call
+	if cache.calls != 4 {
+		t.Errorf("Expected 4 cache calls, got %d", cache.calls)
+	}
+}
+
+func TestCachedCreatorKeysIncludeModel(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache()
+
+	creatorA := &fakeCreator{modelID: "model-a"}
+	if _, err := NewCachedCreator(creatorA, cache).CreateEmbeddings(ctx, []string{"text"}); err != nil {
+		t.Fatalf("Failed to create embeddings: %v", err)
+	}
+
+	creatorB := &fakeCreator{modelID: "model-b"}
+	if _, err := NewCachedCreator(creatorB, cache).CreateEmbeddings(ctx, []string{"text"}); err != nil {
+		t.Fatalf("Failed to create embeddings: %v", err)
+	}
+
+	if len(creatorB.requests) != 1 {
+		t.Errorf("Expected a different model to miss the cache, got %d requests", len(creatorB.requests))
+	}
+}
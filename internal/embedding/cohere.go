@@ -0,0 +1,118 @@
+package embedding
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type CohereRequest struct {
+	Texts     []string `json:"texts"`
+	InputType string   `json:"input_type"`
+	Truncate  string   `json:"truncate,omitempty"`
+}
+
+type CohereResponse struct {
+	ID         string      `json:"id"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// cohereFamily embeds up to 96 texts per request and uses different input types for
+// stored documents and search queries. The v3 models have a fixed output dimension,
+// which is used in place of the configured dimensions.
+type cohereFamily struct{}
+
+var cohereInputTypes = map[InputType]string{
+	InputTypeDocument: "search_document",
+	InputTypeQuery:    "search_query",
+}
+
+func (c *cohereFamily) BuildRequest(texts []string, inputType InputType, dimensions int) ([]byte, error) {
+	cohereInputType, exists := cohereInputTypes[inputType]
+	if !exists {
+		return nil, fmt.Errorf("unsupported input type for cohere: %s", inputType)
+	}
+
+	return json.Marshal(&CohereRequest{
+		Texts:     texts,
+		InputType: cohereInputType,
+		Truncate:  "END",
+	})
+}
+
+func (c *cohereFamily) ParseResponse(body []byte) ([][]float32, error) {
+	var response CohereResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	return response.Embeddings, nil
+}
+
+func (c *cohereFamily) MaxBatchSize() int {
+	return 96
+}
+
+// FixedDimensions is 384 for the light models and 1024 for the others
+func (c *cohereFamily) FixedDimensions(modelID string) int {
+	if strings.Contains(modelID, "light") {
+		return 384
+	}
+	return 1024
+}
+
+type CohereV4Request struct {
+	Texts           []string `json:"texts"`
+	InputType       string   `json:"input_type"`
+	EmbeddingTypes  []string `json:"embedding_types"`
+	OutputDimension int      `json:"output_dimension"`
+	Truncate        string   `json:"truncate,omitempty"`
+}
+
+type CohereV4Response struct {
+	ID         string `json:"id"`
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// cohereV4Dimensions are the output dimensions Embed v4 can return
+var cohereV4Dimensions = []int{256, 512, 1024, 1536}
+
+// cohereV4Family is Embed v4, which takes the dimensions with each request and
+// returns the embeddings keyed by type
+type cohereV4Family struct{}
+
+func (c *cohereV4Family) BuildRequest(texts []string, inputType InputType, dimensions int) ([]byte, error) {
+	cohereInputType, exists := cohereInputTypes[inputType]
+	if !exists {
+		return nil, fmt.Errorf("unsupported input type for cohere: %s", inputType)
+	}
+	if !slices.Contains(cohereV4Dimensions, dimensions) {
+		return nil, fmt.Errorf("cohere embed v4 supports %v dimensions, got %d", cohereV4Dimensions, dimensions)
+	}
+
+	return json.Marshal(&CohereV4Request{
+		Texts:           texts,
+		InputType:       cohereInputType,
+		EmbeddingTypes:  []string{"float"},
+		OutputDimension: dimensions,
+		Truncate:        "RIGHT",
+	})
+}
+
+func (c *cohereV4Family) ParseResponse(body []byte) ([][]float32, error) {
+	var response CohereV4Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	return response.Embeddings.Float, nil
+}
+
+func (c *cohereV4Family) MaxBatchSize() int {
+	return 96
+}
+
+func (c *cohereV4Family) FixedDimensions(modelID string) int {
+	return 0
+}
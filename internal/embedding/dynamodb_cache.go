@@ -0,0 +1,90 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type cacheRecord struct {
+	CacheKey  string `dynamodbav:"cache_key"`
+	ModelID   string `dynamodbav:"model_id"`
+	Embedding []byte `dynamodbav:"embedding_bin"`
+	CreatedAt int64  `dynamodbav:"created_at"`
+}
+
+type DynamoDBCache struct {
+	dynamoDB   aws.DynamoDBClient
+	cacheTable string
+}
+
+func NewDynamoDBCache(dynamoClient aws.DynamoDBClient, cacheTable string) *DynamoDBCache {
+	return &DynamoDBCache{
+		dynamoDB:   dynamoClient,
+		cacheTable: cacheTable,
+	}
+}
+
+// GetEmbeddings returns the cached embeddings by key in one batch, leaving out keys with nothing cached
+func (c *DynamoDBCache) GetEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error) {
+	// BatchGetItem rejects requests that repeat a key
+	itemKeys := make([]map[string]dynamoTypes.AttributeValue, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		itemKeys = append(itemKeys, map[string]dynamoTypes.AttributeValue{
+			"cache_key": &dynamoTypes.AttributeValueMemberS{Value: key},
+		})
+	}
+
+	var records []*cacheRecord
+	if err := c.dynamoDB.BatchGetItems(ctx, c.cacheTable, itemKeys, &records); err != nil {
+		return nil, fmt.Errorf("failed to get cached embeddings: %w", err)
+	}
+
+	embeddings := make(map[string][]float32, len(records))
+	for _, record := range records {
+		embedding, err := utils.DecodeEmbedding(record.Embedding)
+		if err != nil {
+			log.Printf("WARNING: Failed to decode cached embedding %s: %v", record.CacheKey, err)
+			continue
+		}
+		embeddings[record.CacheKey] = embedding
+	}
+
+	return embeddings, nil
+}
+
+// SaveEmbeddings writes the embeddings by key in batches
+func (c *DynamoDBCache) SaveEmbeddings(ctx context.Context, modelID string, embeddings map[string][]float32) error {
+	createdAt := time.Now().Unix()
+	records := make([]interface{}, 0, len(embeddings))
+	for key, embedding := range embeddings {
+		// Always cache full precision, the knowledge repository decides how chunks are stored
+		encoded, err := utils.EncodeEmbedding(embedding, utils.EmbeddingFormatFloat32)
+		if err != nil {
+			return fmt.Errorf("failed to encode embedding: %w", err)
+		}
+
+		records = append(records, &cacheRecord{
+			CacheKey:  key,
+			ModelID:   modelID,
+			Embedding: encoded,
+			CreatedAt: createdAt,
+		})
+	}
+
+	if err := c.dynamoDB.BatchWriteItems(ctx, c.cacheTable, records); err != nil {
+		return fmt.Errorf("failed to save cached embeddings: %w", err)
+	}
+
+	return nil
+}
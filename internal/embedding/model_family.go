@@ -0,0 +1,56 @@
+package embedding
+
+import (
+	"fmt"
+	"strings"
+)
+
+type InputType string
+
+const (
+	// InputTypeDocument is used when embedding rule text for storage
+	InputTypeDocument InputType = "document"
+	// InputTypeQuery is used when embedding a player's question for search
+	InputTypeQuery InputType = "query"
+)
+
+// ModelFamily knows the request and response shapes of a family of Bedrock embedding models
+type ModelFamily interface {
+	BuildRequest(texts []string, inputType InputType, dimensions int) ([]byte, error)
+	ParseResponse(body []byte) ([][]float32, error)
+	// MaxBatchSize is the number of texts a single request can embed
+	MaxBatchSize() int
+	// FixedDimensions is the number of dimensions the model always returns, or 0 when
+	// the dimensions are requested with each call
+	FixedDimensions(modelID string) int
+}
+
+// modelFamilies is keyed by model ID prefix
+var modelFamilies = map[string]ModelFamily{
+	"amazon.titan-embed": &titanFamily{},
+	"cohere.embed":       &cohereFamily{},
+	"cohere.embed-v4":    &cohereV4Family{},
+}
+
+// RegisterModelFamily adds support for the embedding models whose ID starts with prefix
+func RegisterModelFamily(prefix string, family ModelFamily) {
+	modelFamilies[prefix] = family
+}
+
+// GetModelFamily finds the family for a model ID, including cross-region
+// inference profile IDs such as "eu.cohere.embed-english-v3"
+func GetModelFamily(modelID string) (ModelFamily, error) {
+	var matched string
+	for prefix := range modelFamilies {
+		isMatch := strings.HasPrefix(modelID, prefix) || strings.Contains(modelID, "."+prefix)
+		if isMatch && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+
+	if matched == "" {
+		return nil, fmt.Errorf("unsupported embedding model: %s", modelID)
+	}
+
+	return modelFamilies[matched], nil
+}
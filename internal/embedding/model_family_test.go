@@ -0,0 +1,148 @@
+package embedding
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+)
+
+func TestGetModelFamily(t *testing.T) {
+	testCases := []struct {
+		description string
+		modelID     string
+		expected    ModelFamily
+	}{
+		{
+			description: "titan v2",
+			modelID:     "amazon.titan-embed-text-v2:0",
+			expected:    modelFamilies["amazon.titan-embed"],
+		},
+		{
+			description: "cohere english",
+			modelID:     "cohere.embed-english-v3",
+			expected:    modelFamilies["cohere.embed"],
+		},
+		{
+			description: "cross-region inference profile",
+			modelID:     "eu.cohere.embed-multilingual-v3",
+			expected:    modelFamilies["cohere.embed"],
+		},
+		{
+			description: "cohere v4",
+			modelID:     "us.cohere.embed-v4:0",
+			expected:    modelFamilies["cohere.embed-v4"],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			family, err := GetModelFamily(tc.modelID)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if family != tc.expected {
+				t.Errorf("Expected %T, Got %T", tc.expected, family)
+			}
+		})
+	}
+
+	if _, err := GetModelFamily("example.unknown-model"); err == nil {
+		t.Errorf("Expected an error for an unknown model")
+	}
+}
+
+func TestCohereInputTypes(t *testing.T) {
+	family := &cohereFamily{}
+
+	testCases := []struct {
+		description string
+		inputType   InputType
+		expected    string
+	}{
+		{description: "documents", inputType: InputTypeDocument, expected: "search_document"},
+		{description: "queries", inputType: InputTypeQuery, expected: "search_query"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			body, err := family.BuildRequest([]string{"a", "b"}, tc.inputType, 1024)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var request CohereRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				t.Fatalf("Failed to unmarshal request: %v", err)
+			}
+			if request.InputType != tc.expected {
+				t.Errorf("Expected input type %s, Got %s", tc.expected, request.InputType)
+			}
+			if len(request.Texts) != 2 {
+				t.Errorf("Expected 2 texts, Got %d", len(request.Texts))
+			}
+		})
+	}
+}
+
+func TestCohereV4Family(t *testing.T) {
+	family := &cohereV4Family{}
+
+	body, err := family.BuildRequest([]string{"a", "b"}, InputTypeQuery, 512)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var request CohereV4Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("Failed to unmarshal request: %v", err)
+	}
+	if request.InputType != "search_query" || request.OutputDimension != 512 || len(request.EmbeddingTypes) != 1 || request.EmbeddingTypes[0] != "float" {
+		t.Errorf("Expected a search_query request for 512 float dimensions, Got %+v", request)
+	}
+
+	if _, err := family.BuildRequest([]string{"a"}, InputTypeQuery, 300); err == nil {
+		t.Errorf("Expected an error for unsupported dimensions")
+	}
+
+	embeddings, err := family.ParseResponse([]byte(`{"id":"x","embeddings":{"float":[[0.1,0.2],[0.3,0.4]]}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(embeddings) != 2 || embeddings[1][1] != 0.4 {
+		t.Errorf("Expected 2 embeddings, Got %v", embeddings)
+	}
+}
+
+type fakeBedrockClient struct {
+	aws.BedrockClient
+	embeddingModelID string
+}
+
+func (f *fakeBedrockClient) GetEmbeddingModelID() string { return f.embeddingModelID }
+
+func TestBedrockCreatorDimensions(t *testing.T) {
+	testCases := []struct {
+		description string
+		modelID     string
+		expected    int
+	}{
+		{description: "titan uses the configured dimensions", modelID: "amazon.titan-embed-text-v2:0", expected: 256},
+		{description: "cohere uses its fixed dimensions", modelID: "cohere.embed-english-v3", expected: 1024},
+		{description: "cohere light models", modelID: "eu.cohere.embed-english-light-v3", expected: 384},
+		{description: "cohere v4 uses the configured dimensions", modelID: "cohere.embed-v4:0", expected: 256},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			creator, err := NewBedrockCreator(&fakeBedrockClient{embeddingModelID: tc.modelID}, &config.Bedrock{EmbeddingDims: 256})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if creator.GetDimensions() != tc.expected {
+				t.Errorf("Expected %d dimensions, Got %d", tc.expected, creator.GetDimensions())
+			}
+		})
+	}
+}
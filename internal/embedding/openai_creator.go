@@ -0,0 +1,89 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/openai"
+)
+
+// OpenAICreator creates embeddings with an OpenAI compatible embeddings endpoint,
+// which embeds documents and queries the same way
+type OpenAICreator struct {
+	client openai.Client
+	config *config.OpenAI
+}
+
+func NewOpenAICreator(client openai.Client, config *config.OpenAI) *OpenAICreator {
+	log.Printf("Initializing OpenAI compatible embeddings with model: %s, dimensions: %d",
+		client.GetEmbeddingModelID(), config.EmbeddingDims)
+
+	return &OpenAICreator{
+		client: client,
+		config: config,
+	}
+}
+
+func (o *OpenAICreator) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := o.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (o *OpenAICreator) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := max(o.config.EmbeddingBatch, 1)
+	embeddings := make([][]float32, 0, len(texts))
+
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch, err := o.embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed texts %d-%d of %d: %w", start+1, end, len(texts), err)
+		}
+		embeddings = append(embeddings, batch...)
+	}
+
+	return embeddings, nil
+}
+
+func (o *OpenAICreator) GetModelID() string {
+	return o.client.GetEmbeddingModelID()
+}
+
+func (o *OpenAICreator) GetDimensions() int {
+	return o.config.EmbeddingDims
+}
+
+func (o *OpenAICreator) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	response, err := o.client.CreateEmbeddings(ctx, &openai.EmbeddingRequest{
+		Model: o.client.GetEmbeddingModelID(),
+		Input: texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding model %s returned %d embeddings for %d texts",
+			o.GetModelID(), len(response.Data), len(texts))
+	}
+
+	// Servers are not required to return the data in input order
+	embeddings := make([][]float32, len(texts))
+	for _, data := range response.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding model %s returned out of range index %d", o.GetModelID(), data.Index)
+		}
+		if len(data.Embedding) != o.config.EmbeddingDims {
+			return nil, fmt.Errorf("embedding model %s returned %d dimensions, expected %d",
+				o.GetModelID(), len(data.Embedding), o.config.EmbeddingDims)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+
+	return embeddings, nil
+}
@@ -0,0 +1,47 @@
+package embedding
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type TitanRequest struct {
+	InputText  string `json:"inputText"`
+	Dimensions int    `json:"dimensions,omitempty"`
+	Normalize  bool   `json:"normalize,omitempty"`
+}
+
+type TitanResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// titanFamily embeds one text per request and treats documents and queries the same
+type titanFamily struct{}
+
+func (t *titanFamily) BuildRequest(texts []string, inputType InputType, dimensions int) ([]byte, error) {
+	if len(texts) != 1 {
+		return nil, fmt.Errorf("titan embeds one text per request, got %d", len(texts))
+	}
+
+	return json.Marshal(&TitanRequest{
+		InputText:  texts[0],
+		Dimensions: dimensions,
+		Normalize:  true,
+	})
+}
+
+func (t *titanFamily) ParseResponse(body []byte) ([][]float32, error) {
+	var response TitanResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	return [][]float32{response.Embedding}, nil
+}
+
+func (t *titanFamily) MaxBatchSize() int {
+	return 1
+}
+
+func (t *titanFamily) FixedDimensions(modelID string) int {
+	return 0
+}
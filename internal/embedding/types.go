@@ -1,19 +1,18 @@
 package embedding
 
-type Request struct {
-	Text string `json:"inputText"`
-}
-
-type Response struct {
-	Embedding []float64 `json:"embedding"`
-}
+import "context"
 
-type TitanRequest struct {
-	InputText  string `json:"inputText"`
-	Dimensions int    `json:"dimensions,omitempty"`
-	Normalize  bool   `json:"normalize,omitempty"`
+type Creator interface {
+	// CreateEmbedding embeds a search query
+	CreateEmbedding(ctx context.Context, text string) ([]float32, error)
+	// CreateEmbeddings embeds rule text to be stored and searched against
+	CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+	GetModelID() string
+	GetDimensions() int
 }
 
-type TitanResponse struct {
-	Embedding []float64 `json:"embedding"`
+type Cache interface {
+	// GetEmbeddings leaves out the keys that have nothing cached
+	GetEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error)
+	SaveEmbeddings(ctx context.Context, modelID string, embeddings map[string][]float32) error
 }
@@ -0,0 +1,144 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+)
+
+// The rubric is versioned with the code so that scores stay comparable between runs,
+// change it only together with a new baseline
+const graderSystemPrompt = `You are grading answers from an assistant that answers questions about the rules of the board game {game}. The assistant was given excerpts from the rulebook as context and told to answer only from them.
+
+Score the answer on two criteria, each from 1 to 5.
+
+Faithfulness: is every claim in the answer supported by the rulebook context?
+5 - every claim is directly supported by the context
+4 - all important claims are supported, minor details are reasonable inferences
+3 - the main point is supported but some details are not in the context
+2 - important claims are not supported by the context
+1 - the answer contradicts the context or is mostly invented
+
+Correctness: does the answer agree with the reference answer?
+5 - same conclusion and covers every point of the reference answer
+4 - same conclusion, misses minor points
+3 - partly agrees, misses or gets wrong an important point
+2 - mostly disagrees with the reference answer
+1 - contradicts the reference answer
+Use null for correctness when no reference answer is given.
+
+Judge only the content, not the style or length. Citation markers such as [[R1-SETUP,4]] are not claims.
+
+Respond with only a JSON object in this form:
+{"faithfulness": <1-5>, "correctness": <1-5 or null>, "unsupported_claims": ["<claim not supported by the context>"], "reasoning": "<one or two sentences>"}`
+
+const graderUserPrompt = `<rulebook_context>
+{context}
+</rulebook_context>
+
+<question>
+{question}
+</question>
+
+<reference_answer>
+{reference}
+</reference_answer>
+
+<answer>
+{answer}
+</answer>`
+
+const graderMaxTokens = 1000
+
+type BedrockGrader struct {
+	bedrockClient aws.BedrockClient
+}
+
+// The grader uses the client's model, which should be at least as capable as the model being graded
+func NewBedrockGrader(bedrockClient aws.BedrockClient) *BedrockGrader {
+	return &BedrockGrader{
+		bedrockClient: bedrockClient,
+	}
+}
+
+func (g *BedrockGrader) Grade(ctx context.Context, request *GradeRequest) (*Verdict, error) {
+	reference := request.ReferenceAnswer
+	if reference == "" {
+		reference = "None given"
+	}
+
+	replacer := strings.NewReplacer(
+		"{context}", request.Context,
+		"{question}", request.Question,
+		"{reference}", reference,
+		"{answer}", request.Answer,
+	)
+
+	// Grading at temperature 0 keeps the scores repeatable between runs
+	temperature := 0.0
+	response, err := g.bedrockClient.Converse(ctx, &aws.BedrockRequest{
+		System: strings.ReplaceAll(graderSystemPrompt, "{game}", request.GameName),
+		Messages: []aws.BedrockMessage{
+			{Role: "user", Content: replacer.Replace(graderUserPrompt)},
+		},
+		MaxTokens:   graderMaxTokens,
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke grader model: %w", err)
+	}
+
+	var text strings.Builder
+	for _, content := range response.Content {
+		if content.Type == "text" {
+			text.WriteString(content.Text)
+		}
+	}
+
+	verdict, err := parseVerdict(text.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// The model is told to use null, but may still score correctness without a reference
+	if request.ReferenceAnswer == "" {
+		verdict.Correctness = nil
+	}
+
+	return verdict, nil
+}
+
+// parseVerdict reads the first JSON object in the output, since models sometimes wrap
+// it in a code block or add a sentence before or after it
+func parseVerdict(output string) (*Verdict, error) {
+	start := strings.Index(output, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON verdict in grader output: %q", output)
+	}
+
+	var verdict Verdict
+	if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&verdict); err != nil {
+		return nil, fmt.Errorf("invalid JSON verdict: %w", err)
+	}
+
+	if !isValidScore(verdict.Faithfulness) {
+		return nil, fmt.Errorf("faithfulness score out of range: %d", verdict.Faithfulness)
+	}
+	if verdict.Correctness != nil && !isValidScore(*verdict.Correctness) {
+		return nil, fmt.Errorf("correctness score out of range: %d", *verdict.Correctness)
+	}
+
+	return &verdict, nil
+}
+
+func isValidScore(score int) bool {
+	return score >= 1 && score <= 5
+}
+
+// normalizeScore maps a 1 to 5 score onto 0 to 1, like the other metrics
+func normalizeScore(score int) float64 {
+	return float64(score-1) / 4
+}
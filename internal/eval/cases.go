@@ -0,0 +1,67 @@
+package eval
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxLineSize allows cases with long bad answers
+const maxLineSize = 1024 * 1024
+
+// ReadCases reads an evaluation set with one JSON case per line, skipping blank lines
+func ReadCases(r io.Reader) ([]*Case, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var cases []*Case
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var evalCase Case
+		if err := json.Unmarshal([]byte(line), &evalCase); err != nil {
+			return nil, fmt.Errorf("invalid case on line %d: %w", lineNumber, err)
+		}
+		if evalCase.ID == "" {
+			evalCase.ID = CaseID(evalCase.GameName, evalCase.Question)
+		}
+		cases = append(cases, &evalCase)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cases: %w", err)
+	}
+
+	return cases, nil
+}
+
+func WriteCases(w io.Writer, cases []*Case) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	for _, evalCase := range cases {
+		if err := encoder.Encode(evalCase); err != nil {
+			return fmt.Errorf("failed to write case %s: %w", evalCase.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// CaseID is stable for the same question asked with different casing, spacing or punctuation
+func CaseID(gameName, question string) string {
+	hash := sha256.Sum256([]byte(gameName + ":" + normalizeQuestion(question)))
+	return fmt.Sprintf("%x", hash[:8])
+}
+
+func normalizeQuestion(question string) string {
+	question = strings.ToLower(question)
+	question = strings.TrimRight(strings.TrimSpace(question), "?!. ")
+	return strings.Join(strings.Fields(question), " ")
+}
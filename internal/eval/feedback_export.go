@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/feedback"
+)
+
+const exportPageSize = 500
+
+// ExportFeedback turns the negative feedback matching the query into evaluation cases,
+// one per game and question. The question and answer come from the stored message when
+// it exists, otherwise from the last pair in the feedback's conversation context.
+// messageRepo is optional.
+func ExportFeedback(ctx context.Context, lister FeedbackLister, messageRepo MessageRepository, query *feedback.FeedbackQuery) ([]*Case, error) {
+	pageQuery := *query
+	pageQuery.FeedbackType = feedback.FeedbackTypeNegative
+	pageQuery.Limit = exportPageSize
+
+	casesByID := make(map[string]*Case)
+	var order []string
+	skipped := 0
+
+	for {
+		page, err := lister.ListFeedback(ctx, &pageQuery)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list feedback: %w", err)
+		}
+
+		for _, record := range page.Items {
+			question, answer := resolveQuestion(ctx, messageRepo, record)
+			if question == "" {
+				skipped++
+				continue
+			}
+
+			id := CaseID(record.GameName, question)
+			evalCase, exists := casesByID[id]
+			if !exists {
+				evalCase = &Case{
+					ID:       id,
+					GameName: record.GameName,
+					Question: strings.TrimSpace(question),
+				}
+				casesByID[id] = evalCase
+				order = append(order, id)
+			}
+
+			evalCase.addFeedback(record, answer)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		pageQuery.Cursor = page.NextCursor
+	}
+
+	if skipped > 0 {
+		log.Printf("WARNING: Skipped %d feedback records with no question to evaluate", skipped)
+	}
+
+	cases := make([]*Case, len(order))
+	for i, id := range order {
+		cases[i] = casesByID[id]
+		sort.Slice(cases[i].Issues, func(a, b int) bool { return cases[i].Issues[a] < cases[i].Issues[b] })
+	}
+
+	log.Printf("Exported %d evaluation cases from negative feedback", len(cases))
+	return cases, nil
+}
+
+func resolveQuestion(ctx context.Context, messageRepo MessageRepository, record *feedback.FeedbackRecord) (string, string) {
+	if messageRepo != nil && record.MessageID != "" {
+		answered, err := messageRepo.GetMessage(ctx, record.MessageID)
+		if err != nil {
+			log.Printf("WARNING: Failed to get message %s for feedback %s: %v", record.MessageID, record.FeedbackID, err)
+		}
+		if answered != nil {
+			return answered.Question, answered.Answer
+		}
+	}
+
+	if record.ConversationContext == nil || len(record.ConversationContext.RecentQA) == 0 {
+		return "", ""
+	}
+
+	// The rated answer is the most recent pair in the conversation
+	last := record.ConversationContext.RecentQA[len(record.ConversationContext.RecentQA)-1]
+	return last.Question, last.Answer
+}
+
+func (c *Case) addFeedback(record *feedback.FeedbackRecord, answer string) {
+	c.FeedbackIDs = append(c.FeedbackIDs, record.FeedbackID)
+
+	if answer != "" && !contains(c.BadAnswers, answer) {
+		c.BadAnswers = append(c.BadAnswers, answer)
+	}
+
+	for _, issue := range record.Issues {
+		if !contains(c.Issues, issue) {
+			c.Issues = append(c.Issues, issue)
+		}
+	}
+
+	if description := strings.TrimSpace(record.Description); description != "" && !contains(c.Descriptions, description) {
+		c.Descriptions = append(c.Descriptions, description)
+	}
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/feedback"
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
+)
+
+type pagedFeedbackLister struct {
+	pages   []*feedback.FeedbackPage
+	queries []feedback.FeedbackQuery
+}
+
+func (l *pagedFeedbackLister) ListFeedback(ctx context.Context, query *feedback.FeedbackQuery) (*feedback.FeedbackPage, error) {
+	l.queries = append(l.queries, *query)
+	return l.pages[len(l.queries)-1], nil
+}
+
+type fakeMessageRepository struct {
+	messages map[string]*message.Message
+}
+
+func (r *fakeMessageRepository) GetMessage(ctx context.Context, messageID string) (*message.Message, error) {
+	return r.messages[messageID], nil
+}
+
+func recentQA(question, answer string) *feedback.ConversationContext {
+	return &feedback.ConversationContext{
+		RecentQA: []feedback.QAPair{
+			{Question: "An earlier question", Answer: "An earlier answer"},
+			{Question: question, Answer: answer},
+		},
+	}
+}
+
+func TestExportFeedback(t *testing.T) {
+	lister := &pagedFeedbackLister{
+		pages: []*feedback.FeedbackPage{
+			{
+				Items: []*feedback.FeedbackRecord{
+					{
+						FeedbackID:          "f1",
+						GameName:            "wingspan",
+						Issues:              []feedback.FeedbackIssue{feedback.FeedbackIssueUnclear},
+						ConversationContext: recentQA("How many eggs can a bird hold?", "Two"),
+					},
+					{
+						FeedbackID: "f2",
+						GameName:   "wingspan",
+						MessageID:  "m1",
+						Issues:     []feedback.FeedbackIssue{feedback.FeedbackIssueIncorrectInfo},
+						// The stored message wins over the client's conversation context
+						ConversationContext: recentQA("ignored", "ignored"),
+						Description:         "Depends on the bird",
+					},
+				},
+				NextCursor: "next",
+			},
+			{
+				Items: []*feedback.FeedbackRecord{
+					{
+						FeedbackID:          "f3",
+						GameName:            "wingspan",
+						Issues:              []feedback.FeedbackIssue{feedback.FeedbackIssueIncorrectInfo},
+						ConversationContext: recentQA("how many eggs can a bird hold", "Two"),
+					},
+					{FeedbackID: "f4", GameName: "wingspan"},
+					{
+						FeedbackID:          "f5",
+						GameName:            "everdell",
+						ConversationContext: recentQA("How many eggs can a bird hold?", "Not in this game"),
+					},
+				},
+			},
+		},
+	}
+	messageRepo := &fakeMessageRepository{
+		messages: map[string]*message.Message{
+			"m1": {MessageID: "m1", Question: "How many eggs can a bird hold? ", Answer: "Three"},
+		},
+	}
+
+	cases, err := ExportFeedback(context.Background(), lister, messageRepo, &feedback.FeedbackQuery{GameName: "wingspan"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(lister.queries) != 2 || lister.queries[1].Cursor != "next" {
+		t.Fatalf("Expected 2 pages with the cursor passed back, Got %+v", lister.queries)
+	}
+	if lister.queries[0].FeedbackType != feedback.FeedbackTypeNegative {
+		t.Errorf("Expected only negative feedback to be listed, Got %s", lister.queries[0].FeedbackType)
+	}
+
+	if len(cases) != 2 {
+		t.Fatalf("Expected 2 cases, Got %d", len(cases))
+	}
+
+	wingspan := cases[0]
+	if wingspan.GameName != "wingspan" || wingspan.Question != "How many eggs can a bird hold?" {
+		t.Errorf("Expected the first wingspan question, Got %s: %s", wingspan.GameName, wingspan.Question)
+	}
+	if !reflect.DeepEqual(wingspan.BadAnswers, []string{"Two", "Three"}) {
+		t.Errorf("Expected de-duplicated bad answers, Got %v", wingspan.BadAnswers)
+	}
+	expectedIssues := []feedback.FeedbackIssue{feedback.FeedbackIssueIncorrectInfo, feedback.FeedbackIssueUnclear}
+	if !reflect.DeepEqual(wingspan.Issues, expectedIssues) {
+		t.Errorf("Expected issues %v, Got %v", expectedIssues, wingspan.Issues)
+	}
+	if !reflect.DeepEqual(wingspan.FeedbackIDs, []string{"f1", "f2", "f3"}) {
+		t.Errorf("Expected feedback IDs f1, f2 and f3, Got %v", wingspan.FeedbackIDs)
+	}
+	if !reflect.DeepEqual(wingspan.Descriptions, []string{"Depends on the bird"}) {
+		t.Errorf("Expected the description to be kept, Got %v", wingspan.Descriptions)
+	}
+
+	if cases[1].GameName != "everdell" || cases[1].ID == wingspan.ID {
+		t.Errorf("Expected the same question for another game to be a separate case, Got %+v", cases[1])
+	}
+}
+
+func TestCasesRoundTrip(t *testing.T) {
+	cases := []*Case{
+		{ID: "a", GameName: "wingspan", Question: "Can I <discard> food?"},
+		{ID: "b", GameName: "everdell", Question: "When does the game end?", BadAnswers: []string{"Never"}},
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteCases(&buffer, cases); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	read, err := ReadCases(&buffer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(read, cases) {
+		t.Errorf("Expected %+v, Got %+v", cases, read)
+	}
+}
@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+)
+
+func TestParseVerdict(t *testing.T) {
+	testCases := []struct {
+		description          string
+		output               string
+		expectError          bool
+		expectedFaithfulness int
+		expectedCorrectness  *int
+	}{
+		{
+			description:          "Plain JSON",
+			output:               `{"faithfulness": 5, "correctness": 4, "reasoning": "Supported"}`,
+			expectedFaithfulness: 5,
+			expectedCorrectness:  intPointer(4),
+		},
+		{
+			description:          "Wrapped in a code block with null correctness",
+			output:               "Here is my verdict:\n```json\n{\"faithfulness\": 2, \"correctness\": null, \"unsupported_claims\": [\"Birds can hold 5 eggs\"]}\n```",
+			expectedFaithfulness: 2,
+		},
+		{
+			description:          "Note with braces after the JSON",
+			output:               "{\"faithfulness\": 4, \"correctness\": 5}\nNote: the answer omits the {optional} egg limit.",
+			expectedFaithfulness: 4,
+			expectedCorrectness:  intPointer(5),
+		},
+		{
+			description: "No JSON",
+			output:      "The answer looks fine.",
+			expectError: true,
+		},
+		{
+			description: "Score out of range",
+			output:      `{"faithfulness": 9, "correctness": 3}`,
+			expectError: true,
+		},
+		{
+			description: "Missing faithfulness",
+			output:      `{"correctness": 3}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			verdict, err := parseVerdict(tc.output)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, Got verdict %+v", verdict)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if verdict.Faithfulness != tc.expectedFaithfulness {
+				t.Errorf("Expected faithfulness %d, Got %d", tc.expectedFaithfulness, verdict.Faithfulness)
+			}
+			if (verdict.Correctness == nil) != (tc.expectedCorrectness == nil) ||
+				(verdict.Correctness != nil && *verdict.Correctness != *tc.expectedCorrectness) {
+				t.Errorf("Expected correctness %v, Got %v", tc.expectedCorrectness, verdict.Correctness)
+			}
+		})
+	}
+}
+
+func TestRunnerGradesAnswers(t *testing.T) {
+	knowledgeProvider := &fakeKnowledgeProvider{
+		results: map[string][]*knowledge.SearchResult{
+			"Q1": {chunkResult("c1", "rules.md", "Rule one")},
+			"Q2": {chunkResult("c2", "rules.md", "Rule two")},
+		},
+	}
+	answerProvider := &fakeAnswerProvider{answers: map[string]string{"Q1": "A1", "Q2": "A2"}}
+	grader := &StubGrader{Verdict: &Verdict{Faithfulness: 5, Correctness: intPointer(3)}}
+	cases := []*Case{
+		{ID: "1", GameName: "wingspan", Question: "Q1", ReferenceAnswer: "R1"},
+		{ID: "2", GameName: "wingspan", Question: "Q2"},
+	}
+
+	report := NewRunner(knowledgeProvider, answerProvider, grader, nil, 5).Run(context.Background(), "test", cases)
+
+	if len(grader.Requests) != 2 || grader.Requests[0].Context != "rules" || grader.Requests[0].Answer != "A1" {
+		t.Fatalf("Expected both answers to be graded with their context, Got %+v", grader.Requests)
+	}
+
+	assertMetric(t, report.Cases[0].Metrics, MetricFaithfulness, 1)
+	assertMetric(t, report.Cases[0].Metrics, MetricCorrectness, 0.5)
+	if _, exists := report.Cases[1].Metrics[MetricCorrectness]; exists {
+		t.Errorf("Expected no correctness without a reference answer")
+	}
+
+	assertMetric(t, report.Metrics, MetricFaithfulness, 1)
+	if report.MetricCases[MetricCorrectness] != 1 {
+		t.Errorf("Expected correctness to be averaged over 1 case, Got %d", report.MetricCases[MetricCorrectness])
+	}
+}
+
+func TestRunnerKeepsMetricsWhenGradingFails(t *testing.T) {
+	knowledgeProvider := &fakeKnowledgeProvider{
+		results: map[string][]*knowledge.SearchResult{"Q1": {chunkResult("c1", "rules.md", "Rule one")}},
+	}
+	answerProvider := &fakeAnswerProvider{answers: map[string]string{"Q1": "A1"}}
+	grader := &StubGrader{Err: errors.New("throttled")}
+	cases := []*Case{{ID: "1", GameName: "wingspan", Question: "Q1", ExpectedSources: []string{"rules.md"}}}
+
+	report := NewRunner(knowledgeProvider, answerProvider, grader, nil, 5).Run(context.Background(), "test", cases)
+
+	result := report.Cases[0]
+	if result.GradeError != "throttled" || result.Error != "" {
+		t.Errorf("Expected only a grade error, Got error %q and grade error %q", result.Error, result.GradeError)
+	}
+	assertMetric(t, report.Metrics, MetricRecallAtK, 1)
+}
+
+func intPointer(value int) *int {
+	return &value
+}
@@ -0,0 +1,114 @@
+package eval
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
+)
+
+var wordPattern = regexp.MustCompile(`[a-z0-9]+`)
+
+// isRelevant reports whether a retrieved chunk comes from an expected source file or
+// cites an expected reference
+func isRelevant(evalCase *Case, chunk *knowledge.Chunk) bool {
+	for _, source := range evalCase.ExpectedSources {
+		if matchesSource(chunk.SourceFile, source) {
+			return true
+		}
+	}
+
+	for _, referenceID := range evalCase.ExpectedReferences {
+		if strings.Contains(chunk.Content, "[["+referenceID+"]]") || strings.Contains(chunk.Content, "[["+referenceID+",") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesSource(sourceFile, expected string) bool {
+	return sourceFile == expected || strings.HasSuffix(sourceFile, "/"+strings.TrimPrefix(expected, "/"))
+}
+
+// recallAtK is the fraction of expected sources and references found in the top k results
+func recallAtK(evalCase *Case, results []*knowledge.SearchResult, k int) float64 {
+	if len(results) > k {
+		results = results[:k]
+	}
+
+	found := 0
+	for _, source := range evalCase.ExpectedSources {
+		for _, result := range results {
+			if matchesSource(result.Chunk.SourceFile, source) {
+				found++
+				break
+			}
+		}
+	}
+
+	for _, referenceID := range evalCase.ExpectedReferences {
+		onlyReference := &Case{ExpectedReferences: []string{referenceID}}
+		for _, result := range results {
+			if isRelevant(onlyReference, result.Chunk) {
+				found++
+				break
+			}
+		}
+	}
+
+	return float64(found) / float64(len(evalCase.ExpectedSources)+len(evalCase.ExpectedReferences))
+}
+
+// reciprocalRank is one over the rank of the first relevant result, or zero when none is relevant
+func reciprocalRank(evalCase *Case, results []*knowledge.SearchResult) float64 {
+	for i, result := range results {
+		if isRelevant(evalCase, result.Chunk) {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// citedReferences returns the unique reference IDs cited in the answer, in order of appearance
+func citedReferences(answer string) []string {
+	var cited []string
+	for _, citation := range references.ExtractCitations(answer) {
+		if !contains(cited, citation.ReferenceID) {
+			cited = append(cited, citation.ReferenceID)
+		}
+	}
+	return cited
+}
+
+// citationPrecision is the fraction of cited references that were expected
+func citationPrecision(evalCase *Case, cited []string) float64 {
+	correct := 0
+	for _, referenceID := range cited {
+		if contains(evalCase.ExpectedReferences, referenceID) {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(cited))
+}
+
+// missingFacts returns the expected facts that are not in the answer. A fact is in the
+// answer when every word of the fact appears in it, in any order.
+func missingFacts(evalCase *Case, answer string) []string {
+	answerWords := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(strings.ToLower(answer), -1) {
+		answerWords[word] = true
+	}
+
+	var missing []string
+	for _, fact := range evalCase.ExpectedFacts {
+		for _, word := range wordPattern.FindAllString(strings.ToLower(fact), -1) {
+			if !answerWords[word] {
+				missing = append(missing, fact)
+				break
+			}
+		}
+	}
+	return missing
+}
@@ -0,0 +1,164 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// MetricDiff compares one aggregate metric between two reports, every metric is better when higher
+type MetricDiff struct {
+	Metric    string  `json:"metric"`
+	Baseline  float64 `json:"baseline"`
+	Current   float64 `json:"current"`
+	Delta     float64 `json:"delta"`
+	Regressed bool    `json:"regressed"`
+	// BaselineCases and CurrentCases are the number of cases each average is over
+	BaselineCases int `json:"baseline_cases"`
+	CurrentCases  int `json:"current_cases"`
+	// CasesChanged is set when the averages are over different numbers of cases, so the
+	// delta does not compare like with like
+	CasesChanged bool `json:"cases_changed"`
+}
+
+// CaseRegression is a case that scored lower on a metric than in the baseline
+type CaseRegression struct {
+	CaseID   string  `json:"case_id"`
+	Question string  `json:"question"`
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+}
+
+type Comparison struct {
+	Baseline       string            `json:"baseline"`
+	Current        string            `json:"current"`
+	Metrics        []*MetricDiff     `json:"metrics"`
+	Regressions    []*CaseRegression `json:"regressions,omitempty"`
+	BaselineErrors int               `json:"baseline_errors"`
+	CurrentErrors  int               `json:"current_errors"`
+	// Regressed is set when any aggregate metric dropped by more than the tolerance, more
+	// cases failed, or a metric was averaged over a different number of cases
+	Regressed bool `json:"regressed"`
+}
+
+// Compare diffs the current report against the baseline. Cases are matched by ID, so
+// both reports should come from the same evaluation set.
+func Compare(baseline, current *Report, tolerance float64) *Comparison {
+	comparison := &Comparison{
+		Baseline:       baseline.Label,
+		Current:        current.Label,
+		BaselineErrors: baseline.Errors,
+		CurrentErrors:  current.Errors,
+		Regressed:      current.Errors > baseline.Errors,
+	}
+
+	for _, metric := range metricNames(baseline, current) {
+		diff := &MetricDiff{
+			Metric:        metric,
+			Baseline:      baseline.Metrics[metric],
+			Current:       current.Metrics[metric],
+			BaselineCases: baseline.MetricCases[metric],
+			CurrentCases:  current.MetricCases[metric],
+		}
+		diff.Delta = diff.Current - diff.Baseline
+		diff.Regressed = diff.Delta < -tolerance
+		diff.CasesChanged = diff.CurrentCases != diff.BaselineCases
+		comparison.Regressed = comparison.Regressed || diff.Regressed || diff.CasesChanged
+		comparison.Metrics = append(comparison.Metrics, diff)
+	}
+
+	baselineCases := make(map[string]*CaseResult, len(baseline.Cases))
+	for _, result := range baseline.Cases {
+		baselineCases[result.CaseID] = result
+	}
+
+	for _, result := range current.Cases {
+		baselineResult, exists := baselineCases[result.CaseID]
+		if !exists {
+			continue
+		}
+
+		for _, metric := range metricNames(baseline, current) {
+			baselineValue, inBaseline := baselineResult.Metrics[metric]
+			currentValue, inCurrent := result.Metrics[metric]
+			if inBaseline && inCurrent && currentValue < baselineValue {
+				comparison.Regressions = append(comparison.Regressions, &CaseRegression{
+					CaseID:   result.CaseID,
+					Question: result.Question,
+					Metric:   metric,
+					Baseline: baselineValue,
+					Current:  currentValue,
+				})
+			}
+		}
+	}
+
+	return comparison
+}
+
+func ReadReport(r io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	return &report, nil
+}
+
+func WriteReport(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// WriteSummary writes the aggregate metrics of a report as a table
+func WriteSummary(w io.Writer, report *Report) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(table, "Evaluation %s: %d cases, %d errors, k=%d\n", report.Label, len(report.Cases), report.Errors, report.K)
+	fmt.Fprintf(table, "METRIC\tSCORE\tCASES\n")
+	for _, metric := range metricNames(report) {
+		fmt.Fprintf(table, "%s\t%.3f\t%d\n", metric, report.Metrics[metric], report.MetricCases[metric])
+	}
+
+	return table.Flush()
+}
+
+// WriteComparison writes the metric differences and the regressed cases as tables
+func WriteComparison(w io.Writer, comparison *Comparison) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(table, "Comparing %s against baseline %s\n", comparison.Current, comparison.Baseline)
+	if comparison.CurrentErrors > comparison.BaselineErrors {
+		fmt.Fprintf(table, "ERRORS increased from %d to %d\n", comparison.BaselineErrors, comparison.CurrentErrors)
+	}
+	fmt.Fprintf(table, "METRIC\tBASELINE\tCURRENT\tDELTA\tCASES\t\n")
+	for _, diff := range comparison.Metrics {
+		var flags []string
+		if diff.Regressed {
+			flags = append(flags, "REGRESSED")
+		}
+		if diff.CasesChanged {
+			flags = append(flags, "CASES CHANGED")
+		}
+		fmt.Fprintf(table, "%s\t%.3f\t%.3f\t%+.3f\t%d -> %d\t%s\n", diff.Metric, diff.Baseline, diff.Current, diff.Delta,
+			diff.BaselineCases, diff.CurrentCases, strings.Join(flags, ", "))
+	}
+
+	if len(comparison.Regressions) > 0 {
+		fmt.Fprintf(table, "\nCASE\tMETRIC\tBASELINE\tCURRENT\tQUESTION\n")
+		for _, regression := range comparison.Regressions {
+			fmt.Fprintf(table, "%s\t%s\t%.3f\t%.3f\t%s\n",
+				regression.CaseID, regression.Metric, regression.Baseline, regression.Current, regression.Question)
+		}
+	}
+
+	return table.Flush()
+}
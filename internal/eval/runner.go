@@ -0,0 +1,233 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+type Runner struct {
+	knowledgeProvider KnowledgeProvider
+	answerProvider    AnswerProvider
+	grader            Grader
+	overrides         *knowledge.SearchRequest
+	k                 int
+}
+
+// answerProvider is optional, pass nil to only measure retrieval.
+// grader is optional, pass nil to skip grading answers with a model.
+// overrides is optional, its MinSimilarity, MaxTokens and TopK are used for every search.
+// k is the number of top results used for recall@k.
+func NewRunner(knowledgeProvider KnowledgeProvider, answerProvider AnswerProvider, grader Grader, overrides *knowledge.SearchRequest, k int) *Runner {
+	return &Runner{
+		knowledgeProvider: knowledgeProvider,
+		answerProvider:    answerProvider,
+		grader:            grader,
+		overrides:         overrides,
+		k:                 k,
+	}
+}
+
+// Run evaluates each case in turn. A case that fails is recorded in the report with its
+// error and scores zero on the metrics it did not reach, so one bad case does not stop
+// the run but still counts against it.
+func (r *Runner) Run(ctx context.Context, label string, cases []*Case) *Report {
+	report := &Report{
+		Label: label,
+		K:     r.k,
+	}
+
+	for i, evalCase := range cases {
+		log.Printf("Evaluating case %d of %d: %s", i+1, len(cases), evalCase.ID)
+
+		result := r.runCase(ctx, evalCase)
+		if result.Error != "" {
+			log.Printf("WARNING: Case %s failed: %s", evalCase.ID, result.Error)
+			report.Errors++
+		}
+		report.Cases = append(report.Cases, result)
+	}
+
+	report.Metrics, report.MetricCases = averageMetrics(report.Cases)
+	return report
+}
+
+func (r *Runner) runCase(ctx context.Context, evalCase *Case) *CaseResult {
+	result := r.evaluateCase(ctx, evalCase)
+
+	// Errored and unanswered cases would otherwise drop out of the averages, making a
+	// change that answers fewer questions look like an improvement
+	if result.Error != "" || result.Answer == "" {
+		for _, metric := range r.applicableMetrics(evalCase) {
+			if _, exists := result.Metrics[metric]; !exists {
+				result.Metrics[metric] = 0
+			}
+		}
+	}
+
+	return result
+}
+
+// applicableMetrics are the metrics a case is scored on when it is answered
+func (r *Runner) applicableMetrics(evalCase *Case) []string {
+	var metrics []string
+	if len(evalCase.ExpectedSources)+len(evalCase.ExpectedReferences) > 0 {
+		metrics = append(metrics, MetricRecallAtK, MetricMRR)
+	}
+
+	if r.answerProvider == nil {
+		return metrics
+	}
+	if len(evalCase.ExpectedReferences) > 0 {
+		metrics = append(metrics, MetricCitationPrecision)
+	}
+	if len(evalCase.ExpectedFacts) > 0 {
+		metrics = append(metrics, MetricFactCoverage)
+	}
+
+	if r.grader == nil {
+		return metrics
+	}
+	metrics = append(metrics, MetricFaithfulness)
+	if evalCase.ReferenceAnswer != "" {
+		metrics = append(metrics, MetricCorrectness)
+	}
+	return metrics
+}
+
+func (r *Runner) evaluateCase(ctx context.Context, evalCase *Case) *CaseResult {
+	result := &CaseResult{
+		CaseID:   evalCase.ID,
+		GameName: evalCase.GameName,
+		Question: evalCase.Question,
+		Metrics:  make(map[string]float64),
+	}
+
+	// Finding nothing relevant is a retrieval miss rather than a failure
+	retrieved, err := r.knowledgeProvider.GetKnowledge(ctx, r.searchRequest(evalCase), nil)
+	var noKnowledgeErr *knowledge.NoRelevantKnowledgeError
+	if errors.As(err, &noKnowledgeErr) {
+		retrieved, err = &knowledge.RetrievedKnowledge{}, nil
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for _, searchResult := range retrieved.Results {
+		result.RetrievedChunks = append(result.RetrievedChunks, searchResult.Chunk.ID)
+		if !contains(result.RetrievedSources, searchResult.Chunk.SourceFile) {
+			result.RetrievedSources = append(result.RetrievedSources, searchResult.Chunk.SourceFile)
+		}
+	}
+
+	if len(evalCase.ExpectedSources)+len(evalCase.ExpectedReferences) > 0 {
+		result.Metrics[MetricRecallAtK] = recallAtK(evalCase, retrieved.Results, r.k)
+		result.Metrics[MetricMRR] = reciprocalRank(evalCase, retrieved.Results)
+	}
+
+	if r.answerProvider == nil || len(retrieved.Results) == 0 {
+		return result
+	}
+
+	answerResponse, err := r.answerProvider.GenerateAnswer(ctx, &types.AnswerRequest{
+		GameName:  evalCase.GameName,
+		Knowledge: retrieved.Content,
+		Question:  evalCase.Question,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Answer = answerResponse.Answer
+	result.Citations = citedReferences(answerResponse.Answer)
+
+	if len(evalCase.ExpectedReferences) > 0 && len(result.Citations) > 0 {
+		result.Metrics[MetricCitationPrecision] = citationPrecision(evalCase, result.Citations)
+	}
+
+	if len(evalCase.ExpectedFacts) > 0 {
+		result.MissingFacts = missingFacts(evalCase, answerResponse.Answer)
+		covered := len(evalCase.ExpectedFacts) - len(result.MissingFacts)
+		result.Metrics[MetricFactCoverage] = float64(covered) / float64(len(evalCase.ExpectedFacts))
+	}
+
+	r.gradeAnswer(ctx, evalCase, retrieved.Content, result)
+	return result
+}
+
+func (r *Runner) searchRequest(evalCase *Case) *knowledge.SearchRequest {
+	request := &knowledge.SearchRequest{}
+	if r.overrides != nil {
+		*request = *r.overrides
+	}
+
+	request.GameName = evalCase.GameName
+	request.Query = evalCase.Question
+	return request
+}
+
+// gradeAnswer is recorded separately from the case error, so a grading failure keeps
+// the retrieval and answer metrics
+func (r *Runner) gradeAnswer(ctx context.Context, evalCase *Case, retrievedContent string, result *CaseResult) {
+	if r.grader == nil {
+		return
+	}
+
+	verdict, err := r.grader.Grade(ctx, &GradeRequest{
+		GameName:        evalCase.GameName,
+		Question:        evalCase.Question,
+		Context:         retrievedContent,
+		Answer:          result.Answer,
+		ReferenceAnswer: evalCase.ReferenceAnswer,
+	})
+	if err != nil {
+		log.Printf("WARNING: Failed to grade case %s: %v", evalCase.ID, err)
+		result.GradeError = err.Error()
+		return
+	}
+
+	result.Verdict = verdict
+	result.Metrics[MetricFaithfulness] = normalizeScore(verdict.Faithfulness)
+	if verdict.Correctness != nil {
+		result.Metrics[MetricCorrectness] = normalizeScore(*verdict.Correctness)
+	}
+}
+
+func averageMetrics(results []*CaseResult) (map[string]float64, map[string]int) {
+	totals := make(map[string]float64)
+	counts := make(map[string]int)
+
+	for _, result := range results {
+		for metric, value := range result.Metrics {
+			totals[metric] += value
+			counts[metric]++
+		}
+	}
+
+	averages := make(map[string]float64, len(totals))
+	for metric, total := range totals {
+		averages[metric] = total / float64(counts[metric])
+	}
+
+	return averages, counts
+}
+
+// metricNames returns the metrics of the reports in a stable order
+func metricNames(reports ...*Report) []string {
+	var names []string
+	for _, report := range reports {
+		for metric := range report.Metrics {
+			if !contains(names, metric) {
+				names = append(names, metric)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
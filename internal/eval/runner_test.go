@@ -0,0 +1,246 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+type fakeKnowledgeProvider struct {
+	results map[string][]*knowledge.SearchResult
+	err     error
+}
+
+func (p *fakeKnowledgeProvider) GetKnowledge(ctx context.Context, request *knowledge.SearchRequest, history []types.ConversationTurn) (*knowledge.RetrievedKnowledge, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	results, exists := p.results[request.Query]
+	if !exists {
+		return nil, &knowledge.NoRelevantKnowledgeError{GameName: request.GameName, Query: request.Query}
+	}
+	return &knowledge.RetrievedKnowledge{Content: "rules", Results: results}, nil
+}
+
+type fakeAnswerProvider struct {
+	answers map[string]string
+}
+
+func (p *fakeAnswerProvider) GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error) {
+	return &types.AnswerResponse{Answer: p.answers[request.Question]}, nil
+}
+
+func chunkResult(id, sourceFile, content string) *knowledge.SearchResult {
+	return &knowledge.SearchResult{
+		Chunk: &knowledge.Chunk{ID: id, SourceFile: sourceFile, Content: content},
+	}
+}
+
+func assertMetric(t *testing.T, metrics map[string]float64, metric string, expected float64) {
+	t.Helper()
+	value, exists := metrics[metric]
+	if !exists {
+		t.Errorf("Expected metric %s to be set", metric)
+		return
+	}
+	if math.Abs(value-expected) > 1e-9 {
+		t.Errorf("Expected %s of %.3f, Got %.3f", metric, expected, value)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	knowledgeProvider := &fakeKnowledgeProvider{
+		results: map[string][]*knowledge.SearchResult{
+			"How do I lay eggs?": {
+				chunkResult("c1", "games/wingspan/setup.md", "Setup"),
+				chunkResult("c2", "games/wingspan/actions.md", "Lay eggs [[R1-EGGS,4]]"),
+				chunkResult("c3", "games/wingspan/birds.md", "Birds [[R2-BIRDS]]"),
+			},
+		},
+	}
+	answerProvider := &fakeAnswerProvider{
+		answers: map[string]string{
+			"How do I lay eggs?": "Use the lay eggs action to place 2 eggs [[R1-EGGS,4]], limited by nest size [[R9-NESTS]].",
+		},
+	}
+	cases := []*Case{
+		{
+			ID:                 "eggs",
+			GameName:           "wingspan",
+			Question:           "How do I lay eggs?",
+			ExpectedSources:    []string{"actions.md", "scoring.md"},
+			ExpectedReferences: []string{"R1-EGGS"},
+			ExpectedFacts:      []string{"place 2 eggs", "eggs are worth 1 point"},
+		},
+		{
+			ID:              "missing",
+			GameName:        "wingspan",
+			Question:        "Can I trade food?",
+			ExpectedSources: []string{"food.md"},
+		},
+	}
+
+	report := NewRunner(knowledgeProvider, answerProvider, nil, nil, 2).Run(context.Background(), "test", cases)
+
+	if report.Errors != 0 {
+		t.Fatalf("Expected no errors, Got %d", report.Errors)
+	}
+
+	eggs := report.Cases[0]
+	// actions.md and R1-EGGS are in the top 2, scoring.md is not retrieved
+	assertMetric(t, eggs.Metrics, MetricRecallAtK, 2.0/3.0)
+	assertMetric(t, eggs.Metrics, MetricMRR, 0.5)
+	assertMetric(t, eggs.Metrics, MetricCitationPrecision, 0.5)
+	assertMetric(t, eggs.Metrics, MetricFactCoverage, 0.5)
+	if len(eggs.MissingFacts) != 1 || eggs.MissingFacts[0] != "eggs are worth 1 point" {
+		t.Errorf("Expected the scoring fact to be missing, Got %v", eggs.MissingFacts)
+	}
+
+	missing := report.Cases[1]
+	assertMetric(t, missing.Metrics, MetricRecallAtK, 0)
+	assertMetric(t, missing.Metrics, MetricMRR, 0)
+	if missing.Answer != "" {
+		t.Errorf("Expected no answer without retrieved knowledge, Got %s", missing.Answer)
+	}
+
+	assertMetric(t, report.Metrics, MetricRecallAtK, 1.0/3.0)
+	assertMetric(t, report.Metrics, MetricMRR, 0.25)
+	if report.MetricCases[MetricFactCoverage] != 1 {
+		t.Errorf("Expected fact coverage to be averaged over 1 case, Got %d", report.MetricCases[MetricFactCoverage])
+	}
+}
+
+func TestRunnerRecordsErrors(t *testing.T) {
+	knowledgeProvider := &fakeKnowledgeProvider{err: errors.New("throttled")}
+	cases := []*Case{{ID: "a", GameName: "wingspan", Question: "Q", ExpectedSources: []string{"a.md"}}}
+
+	report := NewRunner(knowledgeProvider, nil, nil, nil, 5).Run(context.Background(), "test", cases)
+
+	if report.Errors != 1 || report.Cases[0].Error != "throttled" {
+		t.Errorf("Expected the error to be recorded on the case, Got %d errors: %+v", report.Errors, report.Cases[0])
+	}
+	assertMetric(t, report.Metrics, MetricRecallAtK, 0)
+	if report.MetricCases[MetricRecallAtK] != 1 {
+		t.Errorf("Expected the failed case to count towards recall, Got %d cases", report.MetricCases[MetricRecallAtK])
+	}
+}
+
+func TestRunnerScoresUnansweredCases(t *testing.T) {
+	knowledgeProvider := &fakeKnowledgeProvider{
+		results: map[string][]*knowledge.SearchResult{"Q1": {chunkResult("c1", "rules.md", "Rule one")}},
+	}
+	answerProvider := &fakeAnswerProvider{answers: map[string]string{"Q1": "place 2 eggs"}}
+	grader := &StubGrader{Verdict: &Verdict{Faithfulness: 5, Correctness: intPointer(5)}}
+	cases := []*Case{
+		{ID: "answered", GameName: "wingspan", Question: "Q1", ExpectedFacts: []string{"place 2 eggs"}, ReferenceAnswer: "R1"},
+		{ID: "unanswered", GameName: "wingspan", Question: "Q2", ExpectedFacts: []string{"place 2 eggs"}, ReferenceAnswer: "R2"},
+	}
+
+	report := NewRunner(knowledgeProvider, answerProvider, grader, nil, 5).Run(context.Background(), "test", cases)
+
+	testCases := []struct {
+		description string
+		metric      string
+		expected    float64
+	}{
+		{description: "fact coverage", metric: MetricFactCoverage, expected: 0.5},
+		{description: "faithfulness", metric: MetricFaithfulness, expected: 0.5},
+		{description: "correctness", metric: MetricCorrectness, expected: 0.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			assertMetric(t, report.Cases[1].Metrics, tc.metric, 0)
+			assertMetric(t, report.Metrics, tc.metric, tc.expected)
+			if report.MetricCases[tc.metric] != 2 {
+				t.Errorf("Expected %s to be averaged over 2 cases, Got %d", tc.metric, report.MetricCases[tc.metric])
+			}
+		})
+	}
+
+	if _, exists := report.Cases[1].Metrics[MetricCitationPrecision]; exists {
+		t.Errorf("Expected no citation precision without expected references")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{
+		Label:       "baseline",
+		Metrics:     map[string]float64{MetricRecallAtK: 0.8, MetricMRR: 0.6},
+		MetricCases: map[string]int{MetricRecallAtK: 2, MetricMRR: 2},
+		Cases: []*CaseResult{
+			{CaseID: "a", Metrics: map[string]float64{MetricRecallAtK: 1, MetricMRR: 1}},
+			{CaseID: "b", Metrics: map[string]float64{MetricRecallAtK: 0.6, MetricMRR: 0.2}},
+		},
+	}
+
+	testCases := []struct {
+		description         string
+		current             *Report
+		expectedRegressed   bool
+		expectedRegressions int
+	}{
+		{
+			description: "Small drop within tolerance",
+			current: &Report{
+				Metrics:     map[string]float64{MetricRecallAtK: 0.79, MetricMRR: 0.7},
+				MetricCases: map[string]int{MetricRecallAtK: 2, MetricMRR: 2},
+				Cases: []*CaseResult{
+					{CaseID: "a", Metrics: map[string]float64{MetricRecallAtK: 1, MetricMRR: 1}},
+					{CaseID: "b", Metrics: map[string]float64{MetricRecallAtK: 0.58, MetricMRR: 0.4}},
+				},
+			},
+			expectedRegressed:   false,
+			expectedRegressions: 1,
+		},
+		{
+			description: "Drop beyond tolerance",
+			current: &Report{
+				Metrics:     map[string]float64{MetricRecallAtK: 0.5, MetricMRR: 0.6},
+				MetricCases: map[string]int{MetricRecallAtK: 2, MetricMRR: 2},
+				Cases: []*CaseResult{
+					{CaseID: "a", Metrics: map[string]float64{MetricRecallAtK: 0.5, MetricMRR: 0.5}},
+					{CaseID: "c", Metrics: map[string]float64{MetricRecallAtK: 0, MetricMRR: 0}},
+				},
+			},
+			expectedRegressed:   true,
+			expectedRegressions: 2,
+		},
+		{
+			description: "More cases failed",
+			current: &Report{
+				Metrics:     map[string]float64{MetricRecallAtK: 0.8, MetricMRR: 0.6},
+				MetricCases: map[string]int{MetricRecallAtK: 2, MetricMRR: 2},
+				Errors:      1,
+			},
+			expectedRegressed:   true,
+			expectedRegressions: 0,
+		},
+		{
+			description: "Metric averaged over fewer cases",
+			current: &Report{
+				Metrics:     map[string]float64{MetricRecallAtK: 0.9, MetricMRR: 0.6},
+				MetricCases: map[string]int{MetricRecallAtK: 1, MetricMRR: 2},
+			},
+			expectedRegressed:   true,
+			expectedRegressions: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			comparison := Compare(baseline, tc.current, 0.02)
+
+			if comparison.Regressed != tc.expectedRegressed {
+				t.Errorf("Expected regressed %v, Got %v", tc.expectedRegressed, comparison.Regressed)
+			}
+			if len(comparison.Regressions) != tc.expectedRegressions {
+				t.Errorf("Expected %d case regressions, Got %d", tc.expectedRegressions, len(comparison.Regressions))
+			}
+		})
+	}
+}
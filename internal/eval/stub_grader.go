@@ -0,0 +1,24 @@
+package eval
+
+import "context"
+
+// StubGrader returns the same verdict for every answer and records what it was asked
+// to grade, for running the harness without calling a model
+type StubGrader struct {
+	Verdict  *Verdict
+	Err      error
+	Requests []*GradeRequest
+}
+
+func (g *StubGrader) Grade(ctx context.Context, request *GradeRequest) (*Verdict, error) {
+	g.Requests = append(g.Requests, request)
+	if g.Err != nil {
+		return nil, g.Err
+	}
+
+	verdict := *g.Verdict
+	if request.ReferenceAnswer == "" {
+		verdict.Correctness = nil
+	}
+	return &verdict, nil
+}
@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"context"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/feedback"
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+const (
+	MetricRecallAtK         = "recall_at_k"
+	MetricMRR               = "mrr"
+	MetricCitationPrecision = "citation_precision"
+	MetricFactCoverage      = "fact_coverage"
+	MetricFaithfulness      = "faithfulness"
+	MetricCorrectness       = "correctness"
+)
+
+// Case is one line of an evaluation set
+type Case struct {
+	ID       string `json:"id"`
+	GameName string `json:"game_name"`
+	Question string `json:"question"`
+
+	// ExpectedSources are rule files that should be retrieved, matched against the end of the chunk's source file
+	ExpectedSources []string `json:"expected_sources,omitempty"`
+	// ExpectedReferences are reference IDs that should be retrieved and cited
+	ExpectedReferences []string `json:"expected_references,omitempty"`
+	// ExpectedFacts are short statements the answer should contain
+	ExpectedFacts []string `json:"expected_facts,omitempty"`
+	// ReferenceAnswer is a known good answer used to grade correctness
+	ReferenceAnswer string `json:"reference_answer,omitempty"`
+
+	// Filled in when the case comes from feedback
+	BadAnswers   []string                 `json:"bad_answers,omitempty"`
+	Issues       []feedback.FeedbackIssue `json:"issues,omitempty"`
+	Descriptions []string                 `json:"descriptions,omitempty"`
+	FeedbackIDs  []string                 `json:"feedback_ids,omitempty"`
+}
+
+type FeedbackLister interface {
+	ListFeedback(ctx context.Context, query *feedback.FeedbackQuery) (*feedback.FeedbackPage, error)
+}
+
+type MessageRepository interface {
+	GetMessage(ctx context.Context, messageID string) (*message.Message, error)
+}
+
+// CaseResult holds what was retrieved and answered for a case. Metrics only contains
+// the metrics that apply to the case, for example there is no fact coverage when the
+// case has no expected facts.
+type CaseResult struct {
+	CaseID           string             `json:"case_id"`
+	GameName         string             `json:"game_name"`
+	Question         string             `json:"question"`
+	RetrievedChunks  []string           `json:"retrieved_chunks,omitempty"`
+	RetrievedSources []string           `json:"retrieved_sources,omitempty"`
+	Answer           string             `json:"answer,omitempty"`
+	Citations        []string           `json:"citations,omitempty"`
+	MissingFacts     []string           `json:"missing_facts,omitempty"`
+	Verdict          *Verdict           `json:"verdict,omitempty"`
+	GradeError       string             `json:"grade_error,omitempty"`
+	Metrics          map[string]float64 `json:"metrics"`
+	Error            string             `json:"error,omitempty"`
+}
+
+// Report is the result of running an evaluation set with one configuration
+type Report struct {
+	Label string `json:"label"`
+	K     int    `json:"k"`
+	// Metrics are averaged over the cases each metric applies to, counted in MetricCases
+	Metrics     map[string]float64 `json:"metrics"`
+	MetricCases map[string]int     `json:"metric_cases"`
+	Errors      int                `json:"errors"`
+	Cases       []*CaseResult      `json:"cases"`
+}
+
+type KnowledgeProvider interface {
+	GetKnowledge(ctx context.Context, request *knowledge.SearchRequest, history []types.ConversationTurn) (*knowledge.RetrievedKnowledge, error)
+}
+
+type AnswerProvider interface {
+	GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error)
+}
+
+// GradeRequest is an answer to grade, ReferenceAnswer may be empty
+type GradeRequest struct {
+	GameName        string
+	Question        string
+	Context         string
+	Answer          string
+	ReferenceAnswer string
+}
+
+// Verdict scores an answer from 1 (worst) to 5 (best). Correctness is nil when there
+// was no reference answer to grade against.
+type Verdict struct {
+	Faithfulness      int      `json:"faithfulness"`
+	Correctness       *int     `json:"correctness"`
+	UnsupportedClaims []string `json:"unsupported_claims,omitempty"`
+	Reasoning         string   `json:"reasoning"`
+}
+
+type Grader interface {
+	Grade(ctx context.Context, request *GradeRequest) (*Verdict, error)
+}
@@ -2,23 +2,31 @@ package feedback
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 type DynamoDBRepository struct {
 	dynamoDB      aws.DynamoDBClient
 	feedbackTable string
+	gameIndex     string
 }
 
-func NewDynamoDBRepository(dynamoClient aws.DynamoDBClient, feedbackTable string) *DynamoDBRepository {
+// gameIndex is a global secondary index on the feedback table with game_name as the
+// partition key and created_at as the sort key, used to list feedback for one game
+func NewDynamoDBRepository(dynamoClient aws.DynamoDBClient, feedbackTable, gameIndex string) *DynamoDBRepository {
 	log.Printf("Initializing feedback repository with dynamoDB table: %s", feedbackTable)
 
 	return &DynamoDBRepository{
 		dynamoDB:      dynamoClient,
 		feedbackTable: feedbackTable,
+		gameIndex:     gameIndex,
 	}
 }
 
@@ -31,3 +39,85 @@ func (r *DynamoDBRepository) SaveFeedback(ctx context.Context, feedback *Feedbac
 	log.Printf("Successfully stored feedback: %s for message: %s", feedback.FeedbackID, feedback.MessageID)
 	return nil
 }
+
+// ListFeedback queries the game index, newest first, when a game is given and scans
+// the table otherwise
+func (r *DynamoDBRepository) ListFeedback(ctx context.Context, query *FeedbackQuery) (*FeedbackPage, error) {
+	input := &aws.PageInput{
+		TableName:        r.feedbackTable,
+		ExpressionValues: map[string]dynamoTypes.AttributeValue{},
+		Limit:            int32(query.Limit),
+		Cursor:           query.Cursor,
+	}
+
+	var conditions []string
+	if !query.From.IsZero() {
+		conditions = append(conditions, "created_at >= :from")
+		input.ExpressionValues[":from"] = numberValue(query.From.Unix())
+	}
+	if !query.To.IsZero() {
+		conditions = append(conditions, "created_at <= :to")
+		input.ExpressionValues[":to"] = numberValue(query.To.Unix())
+	}
+
+	var filters []string
+	if query.FeedbackType != "" {
+		filters = append(filters, "feedback_type = :feedback_type")
+		input.ExpressionValues[":feedback_type"] = &dynamoTypes.AttributeValueMemberS{Value: string(query.FeedbackType)}
+	}
+	if query.Issue != "" {
+		filters = append(filters, "contains(issues, :issue)")
+		input.ExpressionValues[":issue"] = &dynamoTypes.AttributeValueMemberS{Value: string(query.Issue)}
+	}
+
+	var records []*FeedbackRecord
+	var nextCursor string
+	var err error
+
+	if query.GameName != "" {
+		// The date range can use the index sort key, the remaining conditions are filters
+		input.IndexName = r.gameIndex
+		input.Descending = true
+		input.ExpressionValues[":game_name"] = &dynamoTypes.AttributeValueMemberS{Value: query.GameName}
+		input.KeyCondition = r.buildKeyCondition(query)
+		input.FilterExpression = strings.Join(filters, " AND ")
+
+		nextCursor, err = r.dynamoDB.QueryPage(ctx, input, &records)
+	} else {
+		input.FilterExpression = strings.Join(append(conditions, filters...), " AND ")
+
+		nextCursor, err = r.dynamoDB.ScanPage(ctx, input, &records)
+	}
+
+	if errors.Is(err, aws.ErrInvalidCursor) {
+		return nil, &ValidationError{
+			Code:    "INVALID_CURSOR",
+			Message: "Cursor is not valid",
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feedback: %w", err)
+	}
+
+	return &FeedbackPage{
+		Items:      records,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (r *DynamoDBRepository) buildKeyCondition(query *FeedbackQuery) string {
+	switch {
+	case !query.From.IsZero() && !query.To.IsZero():
+		return "game_name = :game_name AND created_at BETWEEN :from AND :to"
+	case !query.From.IsZero():
+		return "game_name = :game_name AND created_at >= :from"
+	case !query.To.IsZero():
+		return "game_name = :game_name AND created_at <= :to"
+	default:
+		return "game_name = :game_name"
+	}
+}
+
+func numberValue(value int64) *dynamoTypes.AttributeValueMemberN {
+	return &dynamoTypes.AttributeValueMemberN{Value: strconv.FormatInt(value, 10)}
+}
@@ -7,20 +7,39 @@ import (
 	"strings"
 	"time"
 
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
 	"github.com/google/uuid"
 )
 
 type FeedbackRepository interface {
 	SaveFeedback(ctx context.Context, feedback *FeedbackRecord) error
+	ListFeedback(ctx context.Context, query *FeedbackQuery) (*FeedbackPage, error)
+}
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+	// summaryPageSize is the page size used when reading every matching record for a summary
+	summaryPageSize = 500
+	// summaryMaxPages keeps a summary within the API Gateway timeout, summaries over more
+	// records are marked as truncated
+	summaryMaxPages = 20
+)
+
+type MessageRepository interface {
+	GetMessage(ctx context.Context, messageID string) (*message.Message, error)
 }
 
 type Handler struct {
 	feedbackRepo FeedbackRepository
+	messageRepo  MessageRepository
 }
 
-func NewHandler(feedbackRepo FeedbackRepository) *Handler {
+// messageRepo is optional, without it feedback is accepted for any message ID
+func NewHandler(feedbackRepo FeedbackRepository, messageRepo MessageRepository) *Handler {
 	return &Handler{
 		feedbackRepo: feedbackRepo,
+		messageRepo:  messageRepo,
 	}
 }
 
@@ -33,6 +52,11 @@ func (h *Handler) SubmitFeedback(ctx context.Context, submission *FeedbackSubmis
 		return nil, err
 	}
 
+	if err := h.validateMessage(ctx, submission); err != nil {
+		log.Printf("Message validation failed for feedback submission: %v", err)
+		return nil, err
+	}
+
 	feedback, err := h.createFeedbackRecord(submission)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create feedback record: %w", err)
@@ -126,6 +150,34 @@ func (h *Handler) validateSubmission(submission *FeedbackSubmission) error {
 	return nil
 }
 
+// validateMessage checks that the feedback refers to an answer we gave for the same game
+func (h *Handler) validateMessage(ctx context.Context, submission *FeedbackSubmission) error {
+	if h.messageRepo == nil {
+		return nil
+	}
+
+	answered, err := h.messageRepo.GetMessage(ctx, submission.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+
+	if answered == nil {
+		return &ValidationError{
+			Code:    "UNKNOWN_MESSAGE",
+			Message: "Message not found",
+		}
+	}
+
+	if answered.GameName != submission.GameName {
+		return &ValidationError{
+			Code:    "MESSAGE_GAME_MISMATCH",
+			Message: "Message does not belong to this game",
+		}
+	}
+
+	return nil
+}
+
 func (h *Handler) createFeedbackRecord(submission *FeedbackSubmission) (*FeedbackRecord, error) {
 	timestamp, err := time.Parse(time.RFC3339, submission.Timestamp)
 	if err != nil {
@@ -150,3 +202,114 @@ func (h *Handler) createFeedbackRecord(submission *FeedbackSubmission) (*Feedbac
 
 	return feedback, nil
 }
+
+func (h *Handler) ListFeedback(ctx context.Context, query *FeedbackQuery) (*FeedbackPage, error) {
+	if err := h.validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultListLimit
+	}
+
+	page, err := h.feedbackRepo.ListFeedback(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Listed %d feedback records for game: %q, type: %q, issue: %q",
+		len(page.Items), query.GameName, query.FeedbackType, query.Issue)
+	return page, nil
+}
+
+// SummarizeFeedback counts the records matching the query. It stops after summaryMaxPages
+// pages and marks the summary as truncated, so narrow the date range for exact counts.
+func (h *Handler) SummarizeFeedback(ctx context.Context, query *FeedbackQuery) (*FeedbackSummary, error) {
+	if err := h.validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	summary := &FeedbackSummary{
+		FeedbackCounts: FeedbackCounts{Issues: make(map[FeedbackIssue]int)},
+		Games:          make(map[string]*FeedbackCounts),
+	}
+
+	pageQuery := *query
+	pageQuery.Limit = summaryPageSize
+	pageQuery.Cursor = ""
+
+	for pages := 1; ; pages++ {
+		page, err := h.feedbackRepo.ListFeedback(ctx, &pageQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range page.Items {
+			gameCounts, exists := summary.Games[record.GameName]
+			if !exists {
+				gameCounts = &FeedbackCounts{Issues: make(map[FeedbackIssue]int)}
+				summary.Games[record.GameName] = gameCounts
+			}
+			summary.add(record)
+			gameCounts.add(record)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		if pages >= summaryMaxPages {
+			log.Printf("WARNING: Feedback summary stopped after %d records, narrow the date range for exact counts", summary.Total)
+			summary.Truncated = true
+			break
+		}
+		pageQuery.Cursor = page.NextCursor
+	}
+
+	log.Printf("Summarized %d feedback records across %d games", summary.Total, len(summary.Games))
+	return summary, nil
+}
+
+func (c *FeedbackCounts) add(record *FeedbackRecord) {
+	c.Total++
+	switch record.FeedbackType {
+	case FeedbackTypePositive:
+		c.Positive++
+	case FeedbackTypeNegative:
+		c.Negative++
+	}
+	for _, issue := range record.Issues {
+		c.Issues[issue]++
+	}
+}
+
+func (h *Handler) validateQuery(query *FeedbackQuery) error {
+	if query.FeedbackType != "" && !ValidFeedbackTypes[query.FeedbackType] {
+		return &ValidationError{
+			Code:    "INVALID_FEEDBACK_TYPE",
+			Message: "Feedback type must be one of: positive, negative",
+		}
+	}
+
+	if query.Issue != "" && !ValidFeedbackIssues[query.Issue] {
+		return &ValidationError{
+			Code:    "INVALID_ISSUE",
+			Message: fmt.Sprintf("Invalid issue type: %s", query.Issue),
+		}
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		return &ValidationError{
+			Code:    "INVALID_DATE_RANGE",
+			Message: "From must be before to",
+		}
+	}
+
+	if query.Limit < 0 || query.Limit > maxListLimit {
+		return &ValidationError{
+			Code:    "INVALID_LIMIT",
+			Message: fmt.Sprintf("Limit must be between 1 and %d", maxListLimit),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package feedback
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
+)
+
+type memoryFeedbackRepository struct {
+	saved []*FeedbackRecord
+}
+
+func (m *memoryFeedbackRepository) SaveFeedback(ctx context.Context, feedback *FeedbackRecord) error {
+	m.saved = append(m.saved, feedback)
+	return nil
+}
+
+// ListFeedback returns one saved record per page, with the cursor holding the next index
+func (m *memoryFeedbackRepository) ListFeedback(ctx context.Context, query *FeedbackQuery) (*FeedbackPage, error) {
+	index := 0
+	if query.Cursor != "" {
+		index, _ = strconv.Atoi(query.Cursor)
+	}
+	if index >= len(m.saved) {
+		return &FeedbackPage{}, nil
+	}
+
+	page := &FeedbackPage{Items: []*FeedbackRecord{m.saved[index]}}
+	if index+1 < len(m.saved) {
+		page.NextCursor = strconv.Itoa(index + 1)
+	}
+	return page, nil
+}
+
+type fakeMessageRepository struct {
+	messages map[string]*message.Message
+}
+
+func (f *fakeMessageRepository) GetMessage(ctx context.Context, messageID string) (*message.Message, error) {
+	return f.messages[messageID], nil
+}
+
+func TestSubmitFeedbackValidatesMessage(t *testing.T) {
+	messageRepo := &fakeMessageRepository{messages: map[string]*message.Message{
+		"message-1": {MessageID: "message-1", GameName: "nemesis"},
+	}}
+
+	testCases := []struct {
+		description  string
+		messageID    string
+		gameName     string
+		expectedCode string
+	}{
+		{description: "known message for the game", messageID: "message-1", gameName: "nemesis"},
+		{description: "unknown message", messageID: "invented", gameName: "nemesis", expectedCode: "UNKNOWN_MESSAGE"},
+		{description: "message for another game", messageID: "message-1", gameName: "gloomhaven", expectedCode: "MESSAGE_GAME_MISMATCH"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			feedbackRepo := &memoryFeedbackRepository{}
+			handler := NewHandler(feedbackRepo, messageRepo)
+
+			_, err := handler.SubmitFeedback(context.Background(), &FeedbackSubmission{
+				MessageID:    tc.messageID,
+				GameName:     tc.gameName,
+				FeedbackType: FeedbackTypePositive,
+			})
+
+			if tc.expectedCode == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if len(feedbackRepo.saved) != 1 {
+					t.Errorf("Expected feedback to be saved")
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Code != tc.expectedCode {
+				t.Fatalf("Expected validation error %s, Got %v", tc.expectedCode, err)
+			}
+			if len(feedbackRepo.saved) != 0 {
+				t.Errorf("Expected feedback not to be saved")
+			}
+		})
+	}
+}
+
+func TestSummarizeFeedback(t *testing.T) {
+	feedbackRepo := &memoryFeedbackRepository{saved: []*FeedbackRecord{
+		{GameName: "nemesis", FeedbackType: FeedbackTypePositive},
+		{GameName: "nemesis", FeedbackType: FeedbackTypeNegative, Issues: []FeedbackIssue{FeedbackIssueIncorrectInfo, FeedbackIssueUnclear}},
+		{GameName: "gloomhaven", FeedbackType: FeedbackTypeNegative, Issues: []FeedbackIssue{FeedbackIssueIncorrectInfo}},
+	}}
+	handler := NewHandler(feedbackRepo, nil)
+
+	summary, err := handler.SummarizeFeedback(context.Background(), &FeedbackQuery{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		description string
+		got         int
+		expected    int
+	}{
+		{description: "total across pages", got: summary.Total, expected: 3},
+		{description: "negative total", got: summary.Negative, expected: 2},
+		{description: "incorrect info across games", got: summary.Issues[FeedbackIssueIncorrectInfo], expected: 2},
+		{description: "nemesis total", got: summary.Games["nemesis"].Total, expected: 2},
+		{description: "nemesis positive", got: summary.Games["nemesis"].Positive, expected: 1},
+		{description: "gloomhaven unclear", got: summary.Games["gloomhaven"].Issues[FeedbackIssueUnclear], expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if tc.got != tc.expected {
+				t.Errorf("Expected %d, Got %d", tc.expected, tc.got)
+			}
+		})
+	}
+
+	if summary.Truncated {
+		t.Errorf("Expected the summary not to be truncated")
+	}
+}
+
+func TestSummarizeFeedbackStopsAfterMaxPages(t *testing.T) {
+	feedbackRepo := &memoryFeedbackRepository{}
+	for range summaryMaxPages + 1 {
+		feedbackRepo.saved = append(feedbackRepo.saved, &FeedbackRecord{GameName: "nemesis", FeedbackType: FeedbackTypePositive})
+	}
+	handler := NewHandler(feedbackRepo, nil)
+
+	summary, err := handler.SummarizeFeedback(context.Background(), &FeedbackQuery{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The fake repository returns one record per page
+	if summary.Total != summaryMaxPages || !summary.Truncated {
+		t.Errorf("Expected %d records and a truncated summary, Got %d records and truncated %v", summaryMaxPages, summary.Total, summary.Truncated)
+	}
+}
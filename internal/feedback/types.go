@@ -57,6 +57,39 @@ type FeedbackSubmission struct {
 	Timestamp           string               `json:"timestamp"`
 }
 
+// FeedbackQuery selects feedback to list. Every field is optional, zero values match everything.
+type FeedbackQuery struct {
+	GameName     string
+	FeedbackType FeedbackType
+	Issue        FeedbackIssue
+	From         time.Time
+	To           time.Time
+	Limit        int
+	Cursor       string
+}
+
+// FeedbackPage is one page of feedback, pass NextCursor back to get the following page
+type FeedbackPage struct {
+	Items      []*FeedbackRecord `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type FeedbackCounts struct {
+	Total    int                   `json:"total"`
+	Positive int                   `json:"positive"`
+	Negative int                   `json:"negative"`
+	Issues   map[FeedbackIssue]int `json:"issues"`
+}
+
+// FeedbackSummary holds the counts over all matching feedback and per game
+type FeedbackSummary struct {
+	FeedbackCounts
+	Games map[string]*FeedbackCounts `json:"games"`
+	// Truncated is set when there was too much feedback to count in one request, so the
+	// counts only cover part of the matching records
+	Truncated bool `json:"truncated"`
+}
+
 // FeedbackResponse represents the response after submitting feedback
 type FeedbackResponse struct {
 	FeedbackID string `json:"feedback_id"`
@@ -0,0 +1,50 @@
+package glossary
+
+// Canonicalize replaces slang words and phrases with their canonical rulebook terms.
+// The longest matching phrase wins, words without a glossary entry are kept as-is.
+func (g *Glossary) Canonicalize(words []string) []string {
+	if g == nil || len(g.phrases) == 0 {
+		return words
+	}
+
+	result := make([]string, 0, len(words))
+	for i := 0; i < len(words); {
+		match := g.longestMatch(words[i:])
+		if match == nil {
+			result = append(result, words[i])
+			i++
+			continue
+		}
+
+		result = append(result, match.canonical...)
+		i += len(match.words)
+	}
+
+	return result
+}
+
+func (g *Glossary) longestMatch(words []string) *phrase {
+	var best *phrase
+	for _, candidate := range g.phrases[words[0]] {
+		if len(candidate.words) > len(words) {
+			continue
+		}
+		if best != nil && len(candidate.words) <= len(best.words) {
+			continue
+		}
+
+		matches := true
+		for j, word := range candidate.words {
+			if words[j] != word {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			best = candidate
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,48 @@
+package glossary
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	glossary := New("nemesis", map[string][]string{
+		"AP":          {"action points"},
+		"alien":       {"intruder"},
+		"alien queen": {"queen"},
+		"hit":         {"attack", "damage"},
+		"":            {"ignored"},
+		"empty":       {},
+	})
+
+	testCases := []struct {
+		words       []string
+		expected    []string
+		description string
+	}{
+		{[]string{"how", "much", "ap"}, []string{"how", "much", "action", "points"}, "abbreviation expands to phrase"},
+		{[]string{"can", "the", "alien", "move"}, []string{"can", "the", "intruder", "move"}, "single word replacement"},
+		{[]string{"the", "alien", "queen", "attacks"}, []string{"the", "queen", "attacks"}, "longest phrase wins"},
+		{[]string{"hit", "me"}, []string{"attack", "damage", "me"}, "multiple canonical terms"},
+		{[]string{"empty", "room"}, []string{"empty", "room"}, "terms without canonical entries are ignored"},
+		{[]string{"alien"}, []string{"intruder"}, "phrase longer than remaining words"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			result := glossary.Canonicalize(tc.words)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Words: %q\nExpected: %q\nGot: %q", tc.words, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeNilGlossary(t *testing.T) {
+	var glossary *Glossary
+	words := []string{"alien", "attack"}
+
+	if result := glossary.Canonicalize(words); !reflect.DeepEqual(result, words) {
+		t.Errorf("Expected words to be unchanged, got %q", result)
+	}
+}
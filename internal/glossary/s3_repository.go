@@ -0,0 +1,83 @@
+package glossary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+)
+
+type cachedGlossary struct {
+	glossary *Glossary
+	loadedAt time.Time
+}
+
+// S3Repository loads glossaries from games/<game>/glossary.json, next to the rule files
+type S3Repository struct {
+	s3Client aws.S3Client
+	cacheTTL time.Duration
+	mu       sync.RWMutex
+	cache    map[string]*cachedGlossary
+}
+
+func NewS3Repository(s3Client aws.S3Client, cacheTTL time.Duration) *S3Repository {
+	return &S3Repository{
+		s3Client: s3Client,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]*cachedGlossary),
+	}
+}
+
+// GetGlossary returns an empty glossary when the game does not have a glossary file
+func (r *S3Repository) GetGlossary(ctx context.Context, gameName string) (*Glossary, error) {
+	gameKey := strings.ToLower(gameName)
+
+	r.mu.RLock()
+	cached, exists := r.cache[gameKey]
+	r.mu.RUnlock()
+	if exists && time.Since(cached.loadedAt) < r.cacheTTL {
+		return cached.glossary, nil
+	}
+
+	glossary, err := r.loadGlossary(ctx, gameName)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[gameKey] = &cachedGlossary{glossary: glossary, loadedAt: time.Now()}
+	r.mu.Unlock()
+
+	return glossary, nil
+}
+
+func (r *S3Repository) loadGlossary(ctx context.Context, gameName string) (*Glossary, error) {
+	key := fmt.Sprintf("games/%s/glossary.json", strings.ToLower(gameName))
+
+	content, err := r.s3Client.GetObject(ctx, key)
+	if err != nil {
+		if errors.Is(err, aws.ErrObjectNotFound) {
+			log.Printf("No glossary found for game: %s", gameName)
+			return New(gameName, nil), nil
+		}
+		return nil, fmt.Errorf("failed to get glossary file %s: %w", key, err)
+	}
+
+	var file struct {
+		Terms map[string][]string `json:"terms"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse glossary file %s: %w", key, err)
+	}
+
+	glossary := New(gameName, file.Terms)
+	log.Printf("Loaded glossary with %d terms for game: %s", len(glossary.Terms), gameName)
+
+	return glossary, nil
+}
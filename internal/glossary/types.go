@@ -0,0 +1,58 @@
+package glossary
+
+import (
+	"context"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/tokenizer"
+)
+
+// Glossary maps player slang and abbreviations to the canonical terms used in a game's rulebook,
+// e.g. "ap" -> ["action points"] or "alien" -> ["intruder"]
+type Glossary struct {
+	GameName string              `json:"game_name"`
+	Terms    map[string][]string `json:"terms"`
+
+	// phrases indexes the tokenised slang terms by their first word
+	phrases map[string][]*phrase
+}
+
+type phrase struct {
+	words     []string
+	canonical []string
+}
+
+type Repository interface {
+	GetGlossary(ctx context.Context, gameName string) (*Glossary, error)
+}
+
+// New builds a glossary with lower cased terms, ready for canonicalising tokens
+func New(gameName string, terms map[string][]string) *Glossary {
+	g := &Glossary{
+		GameName: gameName,
+		Terms:    make(map[string][]string, len(terms)),
+		phrases:  make(map[string][]*phrase),
+	}
+
+	for term, canonicalTerms := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		words := tokenizer.Words(term)
+		if len(words) == 0 || len(canonicalTerms) == 0 {
+			continue
+		}
+
+		g.Terms[term] = canonicalTerms
+
+		var canonicalWords []string
+		for _, canonical := range canonicalTerms {
+			canonicalWords = append(canonicalWords, tokenizer.Words(canonical)...)
+		}
+
+		g.phrases[words[0]] = append(g.phrases[words[0]], &phrase{
+			words:     words,
+			canonical: canonicalWords,
+		})
+	}
+
+	return g
+}
@@ -0,0 +1,116 @@
+package grounding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+)
+
+const verifierSystemPrompt = `You check answers about board game rules against excerpts from the rulebook.
+
+For each numbered statement, decide whether the rulebook excerpts support it. A statement is supported when the excerpts state it or it follows directly from them. It is not supported when the excerpts do not mention it, or say something different.
+
+Respond with only a JSON object in this form:
+{"results": [{"statement": <number>, "supported": <true or false>}]}`
+
+const verifierMaxTokens = 1000
+
+type BedrockVerifier struct {
+	bedrockClient aws.BedrockClient
+}
+
+func NewBedrockVerifier(bedrockClient aws.BedrockClient) *BedrockVerifier {
+	return &BedrockVerifier{
+		bedrockClient: bedrockClient,
+	}
+}
+
+type verifierResponse struct {
+	Results []struct {
+		Statement int  `json:"statement"`
+		Supported bool `json:"supported"`
+	} `json:"results"`
+}
+
+func (v *BedrockVerifier) Verify(ctx context.Context, answer string, results []*knowledge.SearchResult) (*Result, error) {
+	var claims []*Claim
+	var statements strings.Builder
+	for _, text := range splitClaims(answer) {
+		claims = append(claims, &Claim{Text: text})
+		fmt.Fprintf(&statements, "%d. %s\n", len(claims), withoutCitations(text))
+	}
+
+	if len(claims) == 0 {
+		return newResult(nil), nil
+	}
+
+	var rulebook strings.Builder
+	for _, result := range results {
+		rulebook.WriteString(result.Chunk.Content)
+		rulebook.WriteString("\n\n")
+	}
+
+	userContent := fmt.Sprintf("<rulebook_context>\n%s</rulebook_context>\n\n<statements>\n%s</statements>", rulebook.String(), statements.String())
+	// Verdicts can remove sentences from answers, so they should not change between runs
+	temperature := 0.0
+	response, err := v.bedrockClient.Converse(ctx, &aws.BedrockRequest{
+		System:      verifierSystemPrompt,
+		Messages:    []aws.BedrockMessage{{Role: "user", Content: userContent}},
+		MaxTokens:   verifierMaxTokens,
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke verifier model: %w", err)
+	}
+
+	var text strings.Builder
+	for _, content := range response.Content {
+		if content.Type == "text" {
+			text.WriteString(content.Text)
+		}
+	}
+
+	verdicts, err := parseVerifierResponse(text.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// Statements the model left out are treated as supported, so that a short response
+	// never removes correct parts of the answer
+	for _, claim := range claims {
+		claim.Supported = true
+		claim.Support = 1
+	}
+	for _, verdict := range verdicts.Results {
+		if verdict.Statement < 1 || verdict.Statement > len(claims) {
+			log.Printf("WARNING: Verifier returned unknown statement %d", verdict.Statement)
+			continue
+		}
+		if !verdict.Supported {
+			claims[verdict.Statement-1].Supported = false
+			claims[verdict.Statement-1].Support = 0
+		}
+	}
+
+	return newResult(claims), nil
+}
+
+// parseVerifierResponse decodes the first JSON object in the output, ignoring any text
+// the model adds before or after it
+func parseVerifierResponse(output string) (*verifierResponse, error) {
+	start := strings.Index(output, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON in verifier output: %q", output)
+	}
+
+	var response verifierResponse
+	if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid verifier JSON: %w", err)
+	}
+	return &response, nil
+}
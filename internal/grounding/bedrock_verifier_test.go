@@ -0,0 +1,87 @@
+package grounding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+)
+
+type fakeBedrockClient struct {
+	aws.BedrockClient
+	output   string
+	requests []*aws.BedrockRequest
+}
+
+func (f *fakeBedrockClient) Converse(ctx context.Context, request *aws.BedrockRequest) (*aws.BedrockResponse, error) {
+	f.requests = append(f.requests, request)
+	return &aws.BedrockResponse{Content: []aws.BedrockContent{{Type: "text", Text: f.output}}}, nil
+}
+
+func TestBedrockVerifier(t *testing.T) {
+	results := []*knowledge.SearchResult{
+		{Chunk: &knowledge.Chunk{ID: "noise", Content: "When a character moves, roll the noise die."}},
+	}
+	answer := "Roll the noise die after moving [[R1-NOISE,12]]. Intruders always retreat. Silence means nothing happens."
+
+	testCases := []struct {
+		description       string
+		output            string
+		expectedSupported []bool
+	}{
+		{
+			description:       "Statements are numbered from 1",
+			output:            `{"results": [{"statement": 1, "supported": true}, {"statement": 2, "supported": false}, {"statement": 3, "supported": true}]}`,
+			expectedSupported: []bool{true, false, true},
+		},
+		{
+			description:       "Missing statements count as supported",
+			output:            `{"results": [{"statement": 2, "supported": false}]}`,
+			expectedSupported: []bool{true, false, true},
+		},
+		{
+			description:       "Text with braces after the JSON",
+			output:            "```json\n{\"results\": [{\"statement\": 2, \"supported\": false}]}\n```\nNote: statement 2 is not in the excerpts {see above}.",
+			expectedSupported: []bool{true, false, true},
+		},
+		{
+			description:       "Unknown statements are ignored",
+			output:            `{"results": [{"statement": 0, "supported": false}, {"statement": 4, "supported": false}]}`,
+			expectedSupported: []bool{true, true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			client := &fakeBedrockClient{output: tc.output}
+			result, err := NewBedrockVerifier(client).Verify(context.Background(), answer, results)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(result.Claims) != len(tc.expectedSupported) {
+				t.Fatalf("Expected %d claims, Got %d", len(tc.expectedSupported), len(result.Claims))
+			}
+			for i, expected := range tc.expectedSupported {
+				if result.Claims[i].Supported != expected {
+					t.Errorf("Expected claim %q supported %v, Got %v", result.Claims[i].Text, expected, result.Claims[i].Supported)
+				}
+			}
+
+			temperature := client.requests[0].Temperature
+			if temperature == nil || *temperature != 0 {
+				t.Errorf("Expected a temperature of 0, Got %v", temperature)
+			}
+		})
+	}
+}
+
+func TestBedrockVerifierInvalidOutput(t *testing.T) {
+	client := &fakeBedrockClient{output: "I cannot check these statements."}
+	results := []*knowledge.SearchResult{{Chunk: &knowledge.Chunk{ID: "noise", Content: "Roll the noise die."}}}
+
+	if _, err := NewBedrockVerifier(client).Verify(context.Background(), "Roll the noise die.", results); err == nil {
+		t.Errorf("Expected an error for output without JSON")
+	}
+}
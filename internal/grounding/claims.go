@@ -0,0 +1,67 @@
+package grounding
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	sentenceEnd     = regexp.MustCompile(`[.!?](\s+|$)`)
+	citationMarker  = regexp.MustCompile(`\[\[[^\]]*\]\]`)
+	listMarker      = regexp.MustCompile(`^\s*([-*•]|\d+[.)])\s*$`)
+	repeatedSpaces  = regexp.MustCompile(`(\S) {2,}`)
+	leadingListItem = regexp.MustCompile(`^([-*•]|\d+[.)])\s+`)
+)
+
+// splitClaims splits an answer into sentences, keeping the original text of each so
+// that it can be found in the answer again
+func splitClaims(answer string) []string {
+	var claims []string
+	for _, line := range strings.Split(answer, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = leadingListItem.ReplaceAllString(line, "")
+
+		start := 0
+		for _, match := range sentenceEnd.FindAllStringIndex(line, -1) {
+			if sentence := strings.TrimSpace(line[start:match[1]]); sentence != "" {
+				claims = append(claims, sentence)
+			}
+			start = match[1]
+		}
+		if sentence := strings.TrimSpace(line[start:]); sentence != "" {
+			claims = append(claims, sentence)
+		}
+	}
+	return claims
+}
+
+// withoutCitations removes citation markers, which are not part of what the claim says
+func withoutCitations(text string) string {
+	return citationMarker.ReplaceAllString(text, "")
+}
+
+// StripUnsupported removes the unsupported claims from the answer, along with list
+// items left empty. The answer is returned unchanged when nothing would be left.
+func StripUnsupported(answer string, result *Result) string {
+	stripped := answer
+	for _, claim := range result.Unsupported() {
+		stripped = strings.Replace(stripped, claim.Text, "", 1)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(stripped, "\n") {
+		if listMarker.MatchString(line) {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(repeatedSpaces.ReplaceAllString(line, "$1 "), " "))
+	}
+	stripped = strings.TrimSpace(strings.Join(lines, "\n"))
+
+	if strings.TrimSpace(withoutCitations(stripped)) == "" {
+		return answer
+	}
+	return stripped
+}
@@ -0,0 +1,71 @@
+package grounding
+
+import (
+	"context"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/tokenizer"
+)
+
+// Sentences with fewer keywords, such as "Yes." or "In short:", are not checked
+const minClaimKeywords = 3
+
+// HeuristicVerifier treats a claim as supported when enough of its keywords appear in a
+// single retrieved chunk. It is cheap enough to run on every answer, but cannot catch a
+// claim that reuses the chunk's words to say something different.
+type HeuristicVerifier struct {
+	tokenizer  *tokenizer.Tokenizer
+	minSupport float64
+}
+
+// minSupport is the fraction of a claim's keywords that must appear in one chunk
+func NewHeuristicVerifier(shortTerms []string, minSupport float64) *HeuristicVerifier {
+	return &HeuristicVerifier{
+		tokenizer:  tokenizer.New(shortTerms),
+		minSupport: minSupport,
+	}
+}
+
+func (v *HeuristicVerifier) Verify(ctx context.Context, answer string, results []*knowledge.SearchResult) (*Result, error) {
+	chunkTokens := make([]map[string]bool, len(results))
+	for i, result := range results {
+		chunkTokens[i] = v.tokenSet(result.Chunk.Content)
+	}
+
+	var claims []*Claim
+	for _, text := range splitClaims(answer) {
+		keywords := v.tokenSet(withoutCitations(text))
+		if len(keywords) < minClaimKeywords {
+			continue
+		}
+
+		claim := &Claim{Text: text}
+		for i, tokens := range chunkTokens {
+			found := 0
+			for keyword := range keywords {
+				if tokens[keyword] {
+					found++
+				}
+			}
+
+			support := float64(found) / float64(len(keywords))
+			if support > claim.Support {
+				claim.Support = support
+				claim.ChunkID = results[i].Chunk.ID
+			}
+		}
+		claim.Supported = claim.Support >= v.minSupport
+
+		claims = append(claims, claim)
+	}
+
+	return newResult(claims), nil
+}
+
+func (v *HeuristicVerifier) tokenSet(text string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, token := range v.tokenizer.Tokenize(text) {
+		tokens[token] = true
+	}
+	return tokens
+}
@@ -0,0 +1,119 @@
+package grounding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+)
+
+func TestSplitClaims(t *testing.T) {
+	answer := "## Noise\nRoll the noise die [[R1-NOISE,12]]. Then place a marker!\n\n- Intruders attack on a 1.\n2. Silence means nothing happens"
+
+	expected := []string{
+		"Roll the noise die [[R1-NOISE,12]].",
+		"Then place a marker!",
+		"Intruders attack on a 1.",
+		"Silence means nothing happens",
+	}
+
+	claims := splitClaims(answer)
+	if len(claims) != len(expected) {
+		t.Fatalf("Expected %d claims, Got %d: %q", len(expected), len(claims), claims)
+	}
+	for i := range expected {
+		if claims[i] != expected[i] {
+			t.Errorf("Expected claim %q, Got %q", expected[i], claims[i])
+		}
+	}
+}
+
+func TestHeuristicVerifier(t *testing.T) {
+	results := []*knowledge.SearchResult{
+		{Chunk: &knowledge.Chunk{ID: "noise", Content: "When a character moves, roll the noise die and place a noise marker in the corridor shown."}},
+		{Chunk: &knowledge.Chunk{ID: "slime", Content: "A character with a slime marker cannot hide."}},
+	}
+	answer := "Roll the noise die after moving [[R1-NOISE,12]]. Slimed characters cannot hide. Intruders always retreat after combat ends.\nYes."
+
+	verifier := NewHeuristicVerifier(nil, 0.6)
+	result, err := verifier.Verify(context.Background(), answer, results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		description       string
+		claim             string
+		expectedSupported bool
+		expectedChunkID   string
+	}{
+		{description: "Claim matching the noise rules", claim: "Roll the noise die after moving [[R1-NOISE,12]].", expectedSupported: true, expectedChunkID: "noise"},
+		{description: "Claim matching the slime rules", claim: "Slimed characters cannot hide.", expectedSupported: true, expectedChunkID: "slime"},
+		{description: "Invented claim", claim: "Intruders always retreat after combat ends.", expectedSupported: false},
+	}
+
+	if len(result.Claims) != len(testCases) {
+		t.Fatalf("Expected %d claims, Got %d", len(testCases), len(result.Claims))
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			claim := result.Claims[i]
+			if claim.Text != tc.claim {
+				t.Fatalf("Expected claim %q, Got %q", tc.claim, claim.Text)
+			}
+			if claim.Supported != tc.expectedSupported {
+				t.Errorf("Expected supported %v, Got %v with support %.2f", tc.expectedSupported, claim.Supported, claim.Support)
+			}
+			if tc.expectedSupported && claim.ChunkID != tc.expectedChunkID {
+				t.Errorf("Expected chunk %s, Got %s", tc.expectedChunkID, claim.ChunkID)
+			}
+		})
+	}
+
+	if result.Score < 0.66 || result.Score > 0.67 {
+		t.Errorf("Expected a score of 2/3, Got %.3f", result.Score)
+	}
+}
+
+func TestStripUnsupported(t *testing.T) {
+	testCases := []struct {
+		description string
+		answer      string
+		unsupported []string
+		expected    string
+	}{
+		{
+			description: "Sentence in a paragraph",
+			answer:      "Roll the die. Intruders retreat. Place a marker.",
+			unsupported: []string{"Intruders retreat."},
+			expected:    "Roll the die. Place a marker.",
+		},
+		{
+			description: "List item",
+			answer:      "Steps:\n- Roll the die.\n- Intruders retreat.\n- Place a marker.",
+			unsupported: []string{"Intruders retreat."},
+			expected:    "Steps:\n- Roll the die.\n- Place a marker.",
+		},
+		{
+			description: "Nothing left",
+			answer:      "Intruders retreat [[R1-X]].",
+			unsupported: []string{"Intruders retreat [[R1-X]]."},
+			expected:    "Intruders retreat [[R1-X]].",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var claims []*Claim
+			for _, text := range tc.unsupported {
+				claims = append(claims, &Claim{Text: text})
+			}
+
+			stripped := StripUnsupported(tc.answer, newResult(claims))
+			if stripped != tc.expected {
+				t.Errorf("Expected %q, Got %q", tc.expected, stripped)
+			}
+		})
+	}
+}
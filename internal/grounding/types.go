@@ -0,0 +1,53 @@
+package grounding
+
+import (
+	"context"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+)
+
+// Claim is a sentence of the answer checked against the retrieved chunks
+type Claim struct {
+	Text      string `json:"text"`
+	Supported bool   `json:"supported"`
+	// Support is how strongly the best matching chunk supports the claim, from 0 to 1
+	Support float64 `json:"support"`
+	ChunkID string  `json:"chunk_id,omitempty"`
+}
+
+type Result struct {
+	// Score is the fraction of claims that are supported, 1 when the answer makes no claims
+	Score  float64  `json:"score"`
+	Claims []*Claim `json:"claims"`
+}
+
+func (r *Result) Unsupported() []*Claim {
+	var unsupported []*Claim
+	for _, claim := range r.Claims {
+		if !claim.Supported {
+			unsupported = append(unsupported, claim)
+		}
+	}
+	return unsupported
+}
+
+type Verifier interface {
+	Verify(ctx context.Context, answer string, results []*knowledge.SearchResult) (*Result, error)
+}
+
+func newResult(claims []*Claim) *Result {
+	result := &Result{Score: 1, Claims: claims}
+	if len(claims) == 0 {
+		return result
+	}
+
+	supported := 0
+	for _, claim := range claims {
+		if claim.Supported {
+			supported++
+		}
+	}
+	result.Score = float64(supported) / float64(len(claims))
+
+	return result
+}
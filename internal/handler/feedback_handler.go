@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/feedback"
 	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
@@ -12,15 +15,22 @@ import (
 
 type FeedbackHandler struct {
 	feedbackHandler *feedback.Handler
+	adminKey        string
 }
 
-func NewFeedbackHandler(feedbackHandler *feedback.Handler) *FeedbackHandler {
+// adminKey protects the GET routes used by the review dashboard, which are disabled when it is empty
+func NewFeedbackHandler(feedbackHandler *feedback.Handler, adminKey string) *FeedbackHandler {
 	return &FeedbackHandler{
 		feedbackHandler: feedbackHandler,
+		adminKey:        adminKey,
 	}
 }
 
 func (h *FeedbackHandler) Handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if request.HTTPMethod == "GET" {
+		return h.handleQuery(ctx, request)
+	}
+
 	if request.HTTPMethod != "POST" {
 		return utils.CreateErrorResponse(405, "Method not allowed"), nil
 	}
@@ -41,6 +51,83 @@ func (h *FeedbackHandler) Handle(ctx context.Context, request events.APIGatewayP
 	return utils.CreateSuccessResponse(response)
 }
 
+// handleQuery serves GET /feedback for a page of feedback and GET /feedback/summary
+// for counts per game and issue. Both accept game_name, feedback_type, issue, from
+// and to query parameters, the list also accepts limit and cursor.
+func (h *FeedbackHandler) handleQuery(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if !utils.IsAdminRequest(request.Headers, h.adminKey) {
+		return utils.CreateErrorResponse(403, "Forbidden"), nil
+	}
+
+	query, err := h.parseFeedbackQuery(request.QueryStringParameters)
+	if err != nil {
+		return utils.CreateErrorResponse(400, err.Error()), nil
+	}
+
+	var response interface{}
+	if strings.HasSuffix(strings.TrimSuffix(request.Path, "/"), "/summary") {
+		response, err = h.feedbackHandler.SummarizeFeedback(ctx, query)
+	} else {
+		response, err = h.feedbackHandler.ListFeedback(ctx, query)
+	}
+
+	if err != nil {
+		if validationErr, ok := err.(*feedback.ValidationError); ok {
+			return utils.CreateErrorResponse(400, validationErr.Message), nil
+		}
+		return utils.CreateErrorResponse(500, err.Error()), nil
+	}
+
+	return utils.CreateSuccessResponse(response)
+}
+
+func (h *FeedbackHandler) parseFeedbackQuery(params map[string]string) (*feedback.FeedbackQuery, error) {
+	query := &feedback.FeedbackQuery{
+		GameName:     params["game_name"],
+		FeedbackType: feedback.FeedbackType(params["feedback_type"]),
+		Issue:        feedback.FeedbackIssue(params["issue"]),
+		Cursor:       params["cursor"],
+	}
+
+	var err error
+	if query.From, err = parseQueryTime(params["from"], false); err != nil {
+		return nil, fmt.Errorf("invalid from: %v", err)
+	}
+	if query.To, err = parseQueryTime(params["to"], true); err != nil {
+		return nil, fmt.Errorf("invalid to: %v", err)
+	}
+
+	if limit := params["limit"]; limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit <= 0 {
+			return nil, fmt.Errorf("invalid limit: %s", limit)
+		}
+	}
+
+	return query, nil
+}
+
+// parseQueryTime accepts RFC3339 timestamps or dates, where a date used as the end
+// of a range includes the whole day
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a date (2006-01-02) or RFC3339 timestamp, got %s", value)
+	}
+
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Second)
+	}
+	return parsed, nil
+}
+
 func (h *FeedbackHandler) parseAndValidateRequest(body string) (*feedback.FeedbackSubmission, error) {
 	var submission feedback.FeedbackSubmission
 	if err := json.Unmarshal([]byte(body), &submission); err != nil {
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/glossary"
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type GlossaryHandler struct {
+	glossaryRepo glossary.Repository
+}
+
+func NewGlossaryHandler(glossaryRepo glossary.Repository) *GlossaryHandler {
+	return &GlossaryHandler{
+		glossaryRepo: glossaryRepo,
+	}
+}
+
+func (h *GlossaryHandler) Handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if request.HTTPMethod != "GET" {
+		return utils.CreateErrorResponse(405, "Method not allowed"), nil
+	}
+
+	gameName := request.PathParameters["gameName"]
+	if gameName == "" {
+		gameName = request.QueryStringParameters["gameName"]
+	}
+	if gameName == "" {
+		return utils.CreateErrorResponse(400, "gameName is required"), nil
+	}
+
+	gameGlossary, err := h.glossaryRepo.GetGlossary(ctx, gameName)
+	if err != nil {
+		return utils.CreateErrorResponse(500, err.Error()), nil
+	}
+
+	return utils.CreateSuccessResponse(gameGlossary)
+}
@@ -10,9 +10,16 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 )
 
+const (
+	ProcessingActionProcess           = "process"
+	ProcessingActionMigrateEmbeddings = "migrate_embeddings"
+	ProcessingActionReembed           = "reembed"
+)
+
 type ProcessingRequest struct {
 	GameName string `json:"game_name"`
 	Force    bool   `json:"force,omitempty"`
+	Action   string `json:"action,omitempty"`
 }
 
 type ProcessingHandler struct {
@@ -31,7 +38,15 @@ func (h *ProcessingHandler) Handle(ctx context.Context, request events.APIGatewa
 		return utils.CreateErrorResponse(400, err.Error()), nil
 	}
 
-	result, err := h.processor.ProcessGame(ctx, req.GameName)
+	var result *knowledge.ProcessingResult
+	switch req.Action {
+	case ProcessingActionMigrateEmbeddings:
+		result, err = h.processor.MigrateEmbeddings(ctx, req.GameName)
+	case ProcessingActionReembed:
+		result, err = h.processor.ReembedGame(ctx, req.GameName, req.Force)
+	default:
+		result, err = h.processor.ProcessGame(ctx, req.GameName)
+	}
 	if err != nil {
 		return utils.CreateErrorResponse(500, err.Error()), nil
 	}
@@ -49,5 +64,14 @@ func (h *ProcessingHandler) parseAndValidateRequest(body string) (*ProcessingReq
 		return nil, fmt.Errorf("game_name is required")
 	}
 
+	if req.Action == "" {
+		req.Action = ProcessingActionProcess
+	}
+	switch req.Action {
+	case ProcessingActionProcess, ProcessingActionMigrateEmbeddings, ProcessingActionReembed:
+	default:
+		return nil, fmt.Errorf("unknown action: %s", req.Action)
+	}
+
 	return &req, nil
 }
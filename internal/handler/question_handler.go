@@ -5,45 +5,137 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
+	"github.com/PhilNel/go-boardgame-assistant/internal/conversation"
+	"github.com/PhilNel/go-boardgame-assistant/internal/grounding"
 	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
 	"github.com/PhilNel/go-boardgame-assistant/internal/logger"
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
 	"github.com/PhilNel/go-boardgame-assistant/internal/references"
 	"github.com/PhilNel/go-boardgame-assistant/internal/types"
 	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
 )
 
 type Request struct {
 	GameName string `json:"gameName"`
 	Question string `json:"question"`
+	// ConversationID continues an earlier conversation, leave empty to start a new one
+	ConversationID string `json:"conversationId,omitempty"`
+	// Debug returns how the answer was produced, and requires the admin key
+	Debug bool `json:"debug,omitempty"`
+	// Search overrides the retrieval limits for this question, and requires the admin key
+	Search *SearchOverrides `json:"search,omitempty"`
+	// FootnoteStyle is how citations are written in the answer (superscript, markdown, html,
+	// brackets or spans), superscript when empty
+	FootnoteStyle string `json:"footnoteStyle,omitempty"`
+}
+
+// SearchOverrides replace the configured retrieval limits, limits left out keep the configured
+// value. A minSimilarity of 0 keeps every chunk.
+type SearchOverrides struct {
+	MinSimilarity *float64 `json:"minSimilarity,omitempty"`
+	MaxTokens     *int     `json:"maxTokens,omitempty"`
+	TopK          *int     `json:"topK,omitempty"`
 }
 
 type Response struct {
-	Answer     string                      `json:"answer"`
-	References []*references.ReferenceInfo `json:"references,omitempty"`
-	Error      string                      `json:"error,omitempty"`
+	Answer         string                      `json:"answer"`
+	References     []*references.ReferenceInfo `json:"references,omitempty"`
+	CitationSpans  []*references.CitationSpan  `json:"citationSpans,omitempty"`
+	ConversationID string                      `json:"conversationId,omitempty"`
+	// MessageID identifies this answer when submitting feedback
+	MessageID string         `json:"messageId,omitempty"`
+	Grounding *GroundingInfo `json:"grounding,omitempty"`
+	Debug     *DebugInfo     `json:"debug,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// GroundingInfo reports how well the answer is supported by the retrieved rules
+type GroundingInfo struct {
+	// Score is the fraction of the answer's sentences supported by the rules
+	Score float64 `json:"score"`
+	// Warnings are the unsupported sentences
+	Warnings []string `json:"warnings,omitempty"`
+	// Stripped is set when the unsupported sentences were removed from the answer
+	Stripped bool `json:"stripped,omitempty"`
+}
+
+// DebugInfo shows how an answer was produced, for reconstructing reported bad answers
+type DebugInfo struct {
+	// Retrieval is only set when the knowledge provider supports tracing
+	Retrieval      *knowledge.RetrievalTrace `json:"retrieval,omitempty"`
+	HistoryTurns   int                       `json:"historyTurns"`
+	PromptTemplate string                    `json:"promptTemplate,omitempty"`
+	Complexity     string                    `json:"complexity,omitempty"`
+	ModelID        string                    `json:"modelId,omitempty"`
+	// RawAnswer is the model output before citations were replaced with footnotes
+	RawAnswer string `json:"rawAnswer,omitempty"`
+	// InvalidCitations are the cited reference IDs that were not in the retrieved chunks
+	InvalidCitations []string `json:"invalidCitations,omitempty"`
 }
 
 type KnowledgeProvider interface {
-	GetKnowledge(ctx context.Context, gameName string, query string) (string, error)
+	GetKnowledge(ctx context.Context, request *knowledge.SearchRequest, history []types.ConversationTurn) (*knowledge.RetrievedKnowledge, error)
 }
 
 type AnswerProvider interface {
-	GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (string, error)
+	GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error)
+}
+
+// TracingKnowledgeProvider is implemented by knowledge providers that can explain how they chose the knowledge
+type TracingKnowledgeProvider interface {
+	GetKnowledgeWithTrace(ctx context.Context, request *knowledge.SearchRequest, history []types.ConversationTurn) (*knowledge.RetrievedKnowledge, *knowledge.RetrievalTrace, error)
+}
+
+// StreamingAnswerProvider is implemented by answer providers that can return the answer as it is generated
+type StreamingAnswerProvider interface {
+	StreamAnswer(ctx context.Context, request *types.AnswerRequest, onText func(text string) error) (*types.AnswerResponse, error)
 }
 
 type QuestionHandler struct {
 	knowledgeProvider  KnowledgeProvider
 	answerProvider     AnswerProvider
 	referenceProcessor references.Processor
+	conversationRepo   conversation.Repository
+	messageRepo        message.Repository
+	maxTurns           int
+	adminKey           string
+	groundingVerifier  grounding.Verifier
+	stripUnsupported   bool
+}
+
+// QuestionHandlerOptions are the optional parts of a QuestionHandler, the zero value
+// answers every question on its own with no admin access or grounding checks
+type QuestionHandlerOptions struct {
+	// ConversationRepo is optional, leave nil to answer every question on its own
+	ConversationRepo conversation.Repository
+	// MessageRepo is optional, leave nil to skip storing answers for feedback
+	MessageRepo message.Repository
+	// MaxTurns is the number of earlier turns used as context for a follow-up question
+	MaxTurns int
+	// AdminKey is required in the x-admin-key header for debug requests, which are disabled when it is empty
+	AdminKey string
+	// GroundingVerifier is optional, leave nil to skip checking answers against the retrieved rules
+	GroundingVerifier grounding.Verifier
+	// StripUnsupported removes unsupported sentences from answers instead of only reporting them
+	StripUnsupported bool
 }
 
-func NewQuestionHandler(knowledgeProvider KnowledgeProvider, answerProvider AnswerProvider, referenceProcessor references.Processor) *QuestionHandler {
+func NewQuestionHandler(knowledgeProvider KnowledgeProvider, answerProvider AnswerProvider, referenceProcessor references.Processor, options QuestionHandlerOptions) *QuestionHandler {
 	return &QuestionHandler{
 		knowledgeProvider:  knowledgeProvider,
 		answerProvider:     answerProvider,
 		referenceProcessor: referenceProcessor,
+		conversationRepo:   options.ConversationRepo,
+		messageRepo:        options.MessageRepo,
+		maxTurns:           options.MaxTurns,
+		adminKey:           options.AdminKey,
+		groundingVerifier:  options.GroundingVerifier,
+		stripUnsupported:   options.StripUnsupported,
 	}
 }
 
@@ -53,7 +145,11 @@ func (h *QuestionHandler) Handle(ctx context.Context, request events.APIGatewayP
 		return utils.CreateErrorResponse(400, err.Error()), nil
 	}
 
-	response, err := h.processQuestion(ctx, req)
+	if h.requiresAdmin(req) && !utils.IsAdminRequest(request.Headers, h.adminKey) {
+		return utils.CreateErrorResponse(403, "Debug mode and search overrides require an admin key"), nil
+	}
+
+	response, err := h.processQuestion(ctx, req, nil)
 	if err != nil {
 		return utils.CreateErrorResponse(500, err.Error()), nil
 	}
@@ -81,45 +177,271 @@ func (h *QuestionHandler) validateRequest(req *Request) error {
 	if req.Question == "" {
 		return fmt.Errorf("question is required")
 	}
+	if _, err := references.NewFootnoteRenderer(req.FootnoteStyle); err != nil {
+		return err
+	}
+	if req.Search != nil {
+		if minSimilarity := req.Search.MinSimilarity; minSimilarity != nil && (*minSimilarity < 0 || *minSimilarity > 1) {
+			return fmt.Errorf("search.minSimilarity must be between 0 and 1")
+		}
+		if (req.Search.MaxTokens != nil && *req.Search.MaxTokens <= 0) || (req.Search.TopK != nil && *req.Search.TopK <= 0) {
+			return fmt.Errorf("search.maxTokens and search.topK must be positive")
+		}
+	}
 	return nil
 }
 
-func (h *QuestionHandler) processQuestion(ctx context.Context, req *Request) (*Response, error) {
+func (h *QuestionHandler) requiresAdmin(req *Request) bool {
+	return req.Debug || req.Search != nil
+}
+
+// processQuestion answers the question, passing the answer text to onText as it is
+// generated when onText is set
+func (h *QuestionHandler) processQuestion(ctx context.Context, req *Request, onText func(text string) error) (*Response, error) {
 	logger.LogIncomingRequest(req.GameName, req.Question)
 
-	knowledgeContent, err := h.knowledgeProvider.GetKnowledge(ctx, req.GameName, req.Question)
+	messageID := uuid.New().String()
+	conv := h.loadConversation(ctx, req)
+	history := conv.RecentTurns(h.maxTurns)
+
+	var debugInfo *DebugInfo
+	if req.Debug {
+		debugInfo = &DebugInfo{HistoryTurns: len(history)}
+	}
+
+	retrieved, err := h.getKnowledge(ctx, req, history, debugInfo)
 	if err != nil {
 		var noKnowledgeErr *knowledge.NoRelevantKnowledgeError
 		if errors.As(err, &noKnowledgeErr) {
 			answer := "I don't have any specific information about that topic in my knowledge base for " + req.GameName +
 				". This might be something we haven't covered yet, or your question might need to be more specific. " +
 				"Feel free to try rephrasing your question or asking about a different aspect of the game!"
-			return &Response{Answer: answer}, nil
+			response := &Response{Answer: answer, MessageID: messageID, Debug: debugInfo}
+			h.saveTurn(ctx, conv, req.Question, answer, response)
+			h.saveMessage(ctx, req, response, nil, nil, 0)
+			return response, nil
 		}
 		return nil, fmt.Errorf("failed to retrieve game knowledge: %w", err)
 	}
 
 	answerRequest := &types.AnswerRequest{
 		GameName:  req.GameName,
-		Knowledge: knowledgeContent,
+		Knowledge: retrieved.Content,
 		Question:  req.Question,
+		History:   history,
 	}
 
-	answer, err := h.answerProvider.GenerateAnswer(ctx, answerRequest)
+	answerResponse, err := h.generateAnswer(ctx, answerRequest, onText)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate response: %w", err)
 	}
 
-	processedResponse, err := h.referenceProcessor.Process(ctx, req.GameName, answer)
+	if debugInfo != nil {
+		debugInfo.PromptTemplate = answerResponse.PromptTemplate
+		debugInfo.Complexity = answerResponse.Complexity
+		debugInfo.ModelID = answerResponse.ModelID
+		debugInfo.RawAnswer = answerResponse.Answer
+	}
+
+	groundingInfo := h.verifyGrounding(ctx, retrieved, answerResponse)
+
+	// The style was checked when validating the request
+	renderer, _ := references.NewFootnoteRenderer(req.FootnoteStyle)
+	processedResponse, err := h.referenceProcessor.Process(ctx, req.GameName, answerResponse.Answer, retrievedReferenceIDs(retrieved), renderer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process references: %w", err)
 	}
+	if debugInfo != nil {
+		debugInfo.InvalidCitations = processedResponse.InvalidCitations
+	}
 
 	response := &Response{
-		Answer:     processedResponse.Response,
-		References: processedResponse.References,
+		Answer:        processedResponse.Response,
+		References:    processedResponse.References,
+		CitationSpans: processedResponse.CitationSpans,
+		MessageID:     messageID,
+		Grounding:     groundingInfo,
+		Debug:         debugInfo,
 	}
+	h.saveTurn(ctx, conv, req.Question, answerResponse.Answer, response)
+	h.saveMessage(ctx, req, response, retrieved, answerResponse, len(processedResponse.InvalidCitations))
 
 	logger.LogSuccessfulQAPair(req.GameName, req.Question, processedResponse.Response)
 	return response, nil
 }
+
+// verifyGrounding checks the answer against the retrieved chunks and, when stripping,
+// removes unsupported sentences from answerResponse. Verification is best effort, the
+// answer is returned unchecked when it fails.
+func (h *QuestionHandler) verifyGrounding(ctx context.Context, retrieved *knowledge.RetrievedKnowledge, answerResponse *types.AnswerResponse) *GroundingInfo {
+	if h.groundingVerifier == nil {
+		return nil
+	}
+
+	result, err := h.groundingVerifier.Verify(ctx, answerResponse.Answer, retrieved.Results)
+	if err != nil {
+		log.Printf("WARNING: Failed to verify answer grounding: %v", err)
+		return nil
+	}
+
+	info := &GroundingInfo{Score: result.Score}
+	unsupported := result.Unsupported()
+	for _, claim := range unsupported {
+		info.Warnings = append(info.Warnings, claim.Text)
+	}
+
+	log.Printf("Answer grounding score: %.2f, %d of %d sentences unsupported", result.Score, len(unsupported), len(result.Claims))
+
+	if h.stripUnsupported && len(unsupported) > 0 {
+		stripped := grounding.StripUnsupported(answerResponse.Answer, result)
+		info.Stripped = stripped != answerResponse.Answer
+		answerResponse.Answer = stripped
+	}
+
+	return info
+}
+
+// retrievedReferenceIDs returns the reference IDs cited in the retrieved chunks, which are the only ones the model can cite truthfully
+func retrievedReferenceIDs(retrieved *knowledge.RetrievedKnowledge) map[string]bool {
+	referenceIDs := make(map[string]bool)
+	for _, result := range retrieved.Results {
+		for _, citation := range references.ExtractCitations(result.Chunk.Content) {
+			referenceIDs[citation.ReferenceID] = true
+		}
+	}
+	return referenceIDs
+}
+
+// getKnowledge applies the request's search overrides and records the retrieval trace in debugInfo when debugging and the knowledge provider supports it
+func (h *QuestionHandler) getKnowledge(ctx context.Context, req *Request, history []types.ConversationTurn, debugInfo *DebugInfo) (*knowledge.RetrievedKnowledge, error) {
+	searchRequest := &knowledge.SearchRequest{
+		GameName: req.GameName,
+		Query:    req.Question,
+	}
+	if req.Search != nil {
+		searchRequest.MinSimilarity = req.Search.MinSimilarity
+		searchRequest.MaxTokens = req.Search.MaxTokens
+		searchRequest.TopK = req.Search.TopK
+	}
+
+	tracingProvider, canTrace := h.knowledgeProvider.(TracingKnowledgeProvider)
+	if debugInfo == nil || !canTrace {
+		return h.knowledgeProvider.GetKnowledge(ctx, searchRequest, history)
+	}
+
+	retrieved, trace, err := tracingProvider.GetKnowledgeWithTrace(ctx, searchRequest, history)
+	debugInfo.Retrieval = trace
+	return retrieved, err
+}
+
+// generateAnswer falls back to sending the whole answer as a single piece of text
+// when the answer provider cannot stream
+func (h *QuestionHandler) generateAnswer(ctx context.Context, answerRequest *types.AnswerRequest, onText func(text string) error) (*types.AnswerResponse, error) {
+	streamingProvider, canStream := h.answerProvider.(StreamingAnswerProvider)
+	if onText != nil && canStream {
+		return streamingProvider.StreamAnswer(ctx, answerRequest, onText)
+	}
+
+	answerResponse, err := h.answerProvider.GenerateAnswer(ctx, answerRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if onText != nil {
+		if err := onText(answerResponse.Answer); err != nil {
+			return nil, err
+		}
+	}
+
+	return answerResponse, nil
+}
+
+// loadConversation returns the conversation to continue, or a new one when the request
+// has no conversation ID, the conversation has expired or it belongs to another game.
+// It returns nil when conversations are disabled.
+func (h *QuestionHandler) loadConversation(ctx context.Context, req *Request) *conversation.Conversation {
+	if h.conversationRepo == nil {
+		return nil
+	}
+
+	newConversation := &conversation.Conversation{
+		ConversationID: uuid.New().String(),
+		GameName:       req.GameName,
+	}
+
+	if req.ConversationID == "" {
+		return newConversation
+	}
+
+	existing, err := h.conversationRepo.GetConversation(ctx, req.ConversationID)
+	if err != nil {
+		log.Printf("WARNING: Failed to load conversation %s, starting a new one: %v", req.ConversationID, err)
+		return newConversation
+	}
+
+	if existing == nil || existing.GameName != req.GameName {
+		log.Printf("Conversation %s not found for game %s, starting a new one", req.ConversationID, req.GameName)
+		return newConversation
+	}
+
+	log.Printf("Continuing conversation %s with %d earlier turns", existing.ConversationID, len(existing.Turns))
+	return existing
+}
+
+// saveTurn is best effort, a failure only means the next question loses this turn as context.
+// answer is the model's raw answer, keeping the [[ID]] citations the prompts ask for rather
+// than footnotes that would be out of place in the next prompt.
+func (h *QuestionHandler) saveTurn(ctx context.Context, conv *conversation.Conversation, question, answer string, response *Response) {
+	if conv == nil {
+		return
+	}
+
+	conv.Turns = append(conv.Turns, types.ConversationTurn{
+		Question: question,
+		Answer:   answer,
+	})
+	conv.Turns = conv.RecentTurns(h.maxTurns)
+
+	if err := h.conversationRepo.SaveConversation(ctx, conv); err != nil {
+		log.Printf("WARNING: Failed to save conversation %s: %v", conv.ConversationID, err)
+	}
+
+	response.ConversationID = conv.ConversationID
+}
+
+// saveMessage records what was answered and how, so that feedback can be joined to it.
+// retrieved and answerResponse are nil when no relevant knowledge was found.
+func (h *QuestionHandler) saveMessage(ctx context.Context, req *Request, response *Response, retrieved *knowledge.RetrievedKnowledge, answerResponse *types.AnswerResponse, invalidCitations int) {
+	if h.messageRepo == nil {
+		return
+	}
+
+	record := &message.Message{
+		MessageID:        response.MessageID,
+		GameName:         req.GameName,
+		ConversationID:   response.ConversationID,
+		Question:         req.Question,
+		Answer:           response.Answer,
+		InvalidCitations: invalidCitations,
+		CreatedAt:        time.Now().Unix(),
+	}
+
+	if retrieved != nil {
+		for _, result := range retrieved.Results {
+			record.RetrievedChunks = append(record.RetrievedChunks, message.RetrievedChunk{
+				ChunkID:    result.Chunk.ID,
+				SourceFile: result.Chunk.SourceFile,
+				Score:      result.Similarity,
+			})
+		}
+	}
+
+	if answerResponse != nil {
+		record.PromptTemplate = answerResponse.PromptTemplate
+		record.ModelID = answerResponse.ModelID
+	}
+
+	if err := h.messageRepo.SaveMessage(ctx, record); err != nil {
+		log.Printf("ERROR: Failed to save message %s, feedback for it will be rejected: %v", record.MessageID, err)
+	}
+}
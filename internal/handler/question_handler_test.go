@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/conversation"
+	"github.com/PhilNel/go-boardgame-assistant/internal/grounding"
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/message"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type recordingAnswerProvider struct {
+	requests []*types.AnswerRequest
+}
+
+func (r *recordingAnswerProvider) GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error) {
+	r.requests = append(r.requests, request)
+	return &types.AnswerResponse{Answer: "Answer to " + request.Question, ModelID: "fake-model", PromptTemplate: "fake"}, nil
+}
+
+type memoryMessageRepository struct {
+	messages map[string]*message.Message
+}
+
+func (m *memoryMessageRepository) SaveMessage(ctx context.Context, msg *message.Message) error {
+	m.messages[msg.MessageID] = msg
+	return nil
+}
+
+func (m *memoryMessageRepository) GetMessage(ctx context.Context, messageID string) (*message.Message, error) {
+	return m.messages[messageID], nil
+}
+
+func TestProcessQuestionConversation(t *testing.T) {
+	ctx := context.Background()
+	answerProvider := &recordingAnswerProvider{}
+	conversationRepo := conversation.NewMemoryRepository(time.Hour)
+	handler := NewQuestionHandler(&fakeKnowledgeProvider{}, answerProvider, &fakeReferenceProcessor{}, QuestionHandlerOptions{ConversationRepo: conversationRepo, MaxTurns: 2})
+
+	first, err := handler.processQuestion(ctx, &Request{GameName: "nemesis", Question: "How do noise rolls work?"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if first.ConversationID == "" {
+		t.Fatalf("Expected a conversation ID for a new conversation")
+	}
+
+	questions := []string{"What about in a corridor?", "And when the intruder is already there?"}
+	for _, question := range questions {
+		response, err := handler.processQuestion(ctx, &Request{GameName: "nemesis", Question: question, ConversationID: first.ConversationID}, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if response.ConversationID != first.ConversationID {
+			t.Errorf("Expected conversation ID %s, Got %s", first.ConversationID, response.ConversationID)
+		}
+	}
+
+	testCases := []struct {
+		description     string
+		request         int
+		expectedHistory []string
+	}{
+		{description: "first question has no history", request: 0, expectedHistory: nil},
+		{description: "follow-up sees the first turn", request: 1, expectedHistory: []string{"How do noise rolls work?"}},
+		{description: "history is capped at max turns", request: 2, expectedHistory: []string{"How do noise rolls work?", "What about in a corridor?"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			history := answerProvider.requests[tc.request].History
+			if len(history) != len(tc.expectedHistory) {
+				t.Fatalf("Expected %d turns, Got %d", len(tc.expectedHistory), len(history))
+			}
+			for i, question := range tc.expectedHistory {
+				if history[i].Question != question {
+					t.Errorf("Expected turn %d to be %q, Got %q", i, question, history[i].Question)
+				}
+			}
+		})
+	}
+
+	stored, err := conversationRepo.GetConversation(ctx, first.ConversationID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(stored.Turns) != 2 {
+		t.Errorf("Expected 2 stored turns, Got %d", len(stored.Turns))
+	}
+
+	otherGame, err := handler.processQuestion(ctx, &Request{GameName: "gloomhaven", Question: "How do I rest?", ConversationID: first.ConversationID}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if otherGame.ConversationID == first.ConversationID {
+		t.Errorf("Expected a new conversation for a different game")
+	}
+}
+
+func TestProcessQuestionStoresRawAnswer(t *testing.T) {
+	ctx := context.Background()
+	conversationRepo := conversation.NewMemoryRepository(time.Hour)
+	answerProvider := &fakeAnswerProvider{pieces: []string{"Roll a d10 [[R1-NOISE,12]]."}}
+	handler := NewQuestionHandler(&fakeKnowledgeProvider{}, answerProvider, &fakeReferenceProcessor{}, QuestionHandlerOptions{ConversationRepo: conversationRepo, MaxTurns: 2})
+
+	response, err := handler.processQuestion(ctx, &Request{GameName: "nemesis", Question: "How do noise rolls work?"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stored, err := conversationRepo.GetConversation(ctx, response.ConversationID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "Roll a d10 [[R1-NOISE,12]]."
+	if len(stored.Turns) != 1 || stored.Turns[0].Answer != expected {
+		t.Errorf("Expected stored answer %q, Got %+v", expected, stored.Turns)
+	}
+	if response.Answer != "Roll a d10¹." {
+		t.Errorf("Expected response answer %q, Got %q", "Roll a d10¹.", response.Answer)
+	}
+}
+
+func TestProcessQuestionSavesMessage(t *testing.T) {
+	ctx := context.Background()
+	messageRepo := &memoryMessageRepository{messages: make(map[string]*message.Message)}
+	handler := NewQuestionHandler(&fakeKnowledgeProvider{}, &recordingAnswerProvider{}, &fakeReferenceProcessor{}, QuestionHandlerOptions{MessageRepo: messageRepo})
+
+	response, err := handler.processQuestion(ctx, &Request{GameName: "nemesis", Question: "How do noise rolls work?"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	saved := messageRepo.messages[response.MessageID]
+	if saved == nil {
+		t.Fatalf("Expected message %s to be saved", response.MessageID)
+	}
+
+	if saved.GameName != "nemesis" || saved.Answer != response.Answer || saved.ModelID != "fake-model" || saved.PromptTemplate != "fake" {
+		t.Errorf("Unexpected saved message: %+v", saved)
+	}
+
+	if len(saved.RetrievedChunks) != 1 || saved.RetrievedChunks[0].ChunkID != "noise" || saved.RetrievedChunks[0].Score != 0.9 {
+		t.Errorf("Expected the retrieved chunk to be saved, Got %+v", saved.RetrievedChunks)
+	}
+}
+
+type tracingKnowledgeProvider struct {
+	fakeKnowledgeProvider
+	requests []*knowledge.SearchRequest
+}
+
+func (f *tracingKnowledgeProvider) GetKnowledge(ctx context.Context, request *knowledge.SearchRequest, history []types.ConversationTurn) (*knowledge.RetrievedKnowledge, error) {
+	f.requests = append(f.requests, request)
+	return f.fakeKnowledgeProvider.GetKnowledge(ctx, request, history)
+}
+
+func (f *tracingKnowledgeProvider) GetKnowledgeWithTrace(ctx context.Context, request *knowledge.SearchRequest, history []types.ConversationTurn) (*knowledge.RetrievedKnowledge, *knowledge.RetrievalTrace, error) {
+	retrieved, err := f.GetKnowledge(ctx, request, history)
+	trace := &knowledge.RetrievalTrace{Queries: []string{request.Query}, SelectedTokens: 42}
+	return retrieved, trace, err
+}
+
+func TestHandleDebug(t *testing.T) {
+	answerProvider := &fakeAnswerProvider{pieces: []string{"Roll a d10", " [[R1-NOISE,12]]", "."}}
+	knowledgeProvider := &tracingKnowledgeProvider{}
+	handler := NewQuestionHandler(knowledgeProvider, answerProvider, &fakeReferenceProcessor{}, QuestionHandlerOptions{AdminKey: "secret"})
+
+	testCases := []struct {
+		description    string
+		body           string
+		headers        map[string]string
+		expectedStatus int
+		expectDebug    bool
+	}{
+		{
+			description:    "Debug with the admin key",
+			body:           `{"gameName":"nemesis","question":"How do noise rolls work?","debug":true}`,
+			headers:        map[string]string{"X-Admin-Key": "secret"},
+			expectedStatus: 200,
+			expectDebug:    true,
+		},
+		{
+			description:    "Debug without the admin key",
+			body:           `{"gameName":"nemesis","question":"How do noise rolls work?","debug":true}`,
+			expectedStatus: 403,
+		},
+		{
+			description:    "Search overrides without the admin key",
+			body:           `{"gameName":"nemesis","question":"How do noise rolls work?","search":{"topK":3}}`,
+			expectedStatus: 403,
+		},
+		{
+			description:    "Search overrides out of range",
+			body:           `{"gameName":"nemesis","question":"How do noise rolls work?","search":{"minSimilarity":2}}`,
+			headers:        map[string]string{"X-Admin-Key": "secret"},
+			expectedStatus: 400,
+		},
+		{
+			description:    "Unknown footnote style",
+			body:           `{"gameName":"nemesis","question":"How do noise rolls work?","footnoteStyle":"roman"}`,
+			expectedStatus: 400,
+		},
+		{
+			description:    "No debug",
+			body:           `{"gameName":"nemesis","question":"How do noise rolls work?"}`,
+			headers:        map[string]string{"X-Admin-Key": "secret"},
+			expectedStatus: 200,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			apiResponse, err := handler.Handle(context.Background(), events.APIGatewayProxyRequest{Body: tc.body, Headers: tc.headers})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if apiResponse.StatusCode != tc.expectedStatus {
+				t.Fatalf("Expected status %d, Got %d", tc.expectedStatus, apiResponse.StatusCode)
+			}
+			if tc.expectedStatus != 200 {
+				return
+			}
+
+			var response Response
+			if err := json.Unmarshal([]byte(apiResponse.Body), &response); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !tc.expectDebug {
+				if response.Debug != nil {
+					t.Errorf("Expected no debug info, Got %+v", response.Debug)
+				}
+				return
+			}
+
+			if response.Debug == nil {
+				t.Fatalf("Expected debug info")
+			}
+			if response.Debug.RawAnswer != "Roll a d10 [[R1-NOISE,12]]." {
+				t.Errorf("Expected the raw answer with citations, Got %q", response.Debug.RawAnswer)
+			}
+			if response.Debug.Retrieval == nil || response.Debug.Retrieval.SelectedTokens != 42 {
+				t.Errorf("Expected the retrieval trace, Got %+v", response.Debug.Retrieval)
+			}
+			if response.Debug.ModelID != "fake-model" {
+				t.Errorf("Expected model fake-model, Got %s", response.Debug.ModelID)
+			}
+		})
+	}
+}
+
+func TestHandleSearchOverrides(t *testing.T) {
+	knowledgeProvider := &tracingKnowledgeProvider{}
+	handler := NewQuestionHandler(knowledgeProvider, &recordingAnswerProvider{}, &fakeReferenceProcessor{}, QuestionHandlerOptions{AdminKey: "secret"})
+
+	request := events.APIGatewayProxyRequest{
+		Body:    `{"gameName":"nemesis","question":"How do noise rolls work?","search":{"minSimilarity":0,"maxTokens":800,"topK":3}}`,
+		Headers: map[string]string{"x-admin-key": "secret"},
+	}
+	apiResponse, err := handler.Handle(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if apiResponse.StatusCode != 200 {
+		t.Fatalf("Expected status 200, Got %d: %s", apiResponse.StatusCode, apiResponse.Body)
+	}
+
+	// A minimum similarity of zero is an override rather than the configured value
+	minSimilarity, maxTokens, topK := 0.0, 800, 3
+	expected := &knowledge.SearchRequest{GameName: "nemesis", Query: "How do noise rolls work?", MinSimilarity: &minSimilarity, MaxTokens: &maxTokens, TopK: &topK}
+	if len(knowledgeProvider.requests) != 1 || !reflect.DeepEqual(knowledgeProvider.requests[0], expected) {
+		t.Errorf("Expected search request %+v, Got %+v", expected, knowledgeProvider.requests)
+	}
+}
+
+type fakeGroundingVerifier struct {
+	unsupported string
+}
+
+func (f *fakeGroundingVerifier) Verify(ctx context.Context, answer string, results []*knowledge.SearchResult) (*grounding.Result, error) {
+	return &grounding.Result{
+		Score: 0.5,
+		Claims: []*grounding.Claim{
+			{Text: "Roll a d10 [[R1-NOISE,12]].", Supported: true},
+			{Text: f.unsupported, Supported: false},
+		},
+	}, nil
+}
+
+func TestProcessQuestionGrounding(t *testing.T) {
+	answerProvider := &fakeAnswerProvider{pieces: []string{"Roll a d10 [[R1-NOISE,12]].", " Intruders flee."}}
+	verifier := &fakeGroundingVerifier{unsupported: "Intruders flee."}
+
+	testCases := []struct {
+		description      string
+		stripUnsupported bool
+		expectedAnswer   string
+	}{
+		{description: "Flag unsupported sentences", stripUnsupported: false, expectedAnswer: "Roll a d10¹. Intruders flee."},
+		{description: "Strip unsupported sentences", stripUnsupported: true, expectedAnswer: "Roll a d10¹."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			handler := NewQuestionHandler(&fakeKnowledgeProvider{}, answerProvider, &fakeReferenceProcessor{}, QuestionHandlerOptions{GroundingVerifier: verifier, StripUnsupported: tc.stripUnsupported})
+
+			response, err := handler.processQuestion(context.Background(), &Request{GameName: "nemesis", Question: "How do noise rolls work?"}, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if response.Answer != tc.expectedAnswer {
+				t.Errorf("Expected answer %q, Got %q", tc.expectedAnswer, response.Answer)
+			}
+			if response.Grounding == nil || response.Grounding.Score != 0.5 || len(response.Grounding.Warnings) != 1 {
+				t.Fatalf("Expected a grounding score with one warning, Got %+v", response.Grounding)
+			}
+			if response.Grounding.Stripped != tc.stripUnsupported {
+				t.Errorf("Expected stripped %v, Got %v", tc.stripUnsupported, response.Grounding.Stripped)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	// StreamEventDelta carries the next piece of the raw answer text
+	StreamEventDelta = "delta"
+	// StreamEventDone carries the final Response with the footnoted answer and references
+	StreamEventDone = "done"
+	// StreamEventError carries a Response with only the error set
+	StreamEventError = "error"
+)
+
+type StreamDelta struct {
+	Text string `json:"text"`
+}
+
+// HandleStream answers a question for a Lambda function URL using response streaming
+func (h *QuestionHandler) HandleStream(ctx context.Context, request events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error) {
+	body := request.Body
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return utils.CreateStreamingErrorResponse(400, "Invalid base64 request body"), nil
+		}
+		body = string(decoded)
+	}
+
+	req, err := h.parseAndValidateRequest(body)
+	if err != nil {
+		return utils.CreateStreamingErrorResponse(400, err.Error()), nil
+	}
+
+	if h.requiresAdmin(req) && !utils.IsAdminRequest(request.Headers, h.adminKey) {
+		return utils.CreateStreamingErrorResponse(403, "Debug mode and search overrides require an admin key"), nil
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		defer writer.Close()
+		if err := h.StreamQuestion(ctx, req, writer); err != nil {
+			log.Printf("ERROR: Failed to write answer stream: %v", err)
+		}
+	}()
+
+	return utils.CreateStreamingResponse(reader), nil
+}
+
+// StreamQuestion writes the answer to w as server-sent events: delta events as the
+// model generates the answer, then a done event. Deltas contain the raw answer with
+// citations such as [[R1-SLIME,17]], so clients should replace the streamed text with
+// the footnoted answer from the done event. Writers implementing http.Flusher are
+// flushed after every event, so this also works for chunked HTTP responses.
+func (h *QuestionHandler) StreamQuestion(ctx context.Context, req *Request, w io.Writer) (err error) {
+	// The stream is written from its own goroutine, where a panic would not reach the
+	// Lambda handler's recovery, so it is ended with an error event instead
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC: Answer stream panicked: %v", r)
+			err = writeStreamEvent(w, StreamEventError, &Response{Error: "Internal server error"})
+		}
+	}()
+
+	onText := func(text string) error {
+		return writeStreamEvent(w, StreamEventDelta, &StreamDelta{Text: text})
+	}
+
+	response, err := h.processQuestion(ctx, req, onText)
+	if err != nil {
+		log.Printf("ERROR: Failed to stream answer: %v", err)
+		return writeStreamEvent(w, StreamEventError, &Response{Error: err.Error()})
+	}
+
+	return writeStreamEvent(w, StreamEventDone, response)
+}
+
+func writeStreamEvent(w io.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event, err)
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event, err)
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
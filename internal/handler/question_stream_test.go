@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type fakeKnowledgeProvider struct{}
+
+func (f *fakeKnowledgeProvider) GetKnowledge(ctx context.Context, request *knowledge.SearchRequest, history []types.ConversationTurn) (*knowledge.RetrievedKnowledge, error) {
+	chunk := &knowledge.Chunk{ID: "noise", SourceFile: "games/nemesis/noise.md", Content: "Noise rolls use a d10 [[R1-NOISE,12]]"}
+	return &knowledge.RetrievedKnowledge{
+		Content: chunk.Content,
+		Results: []*knowledge.SearchResult{{Chunk: chunk, Similarity: 0.9}},
+	}, nil
+}
+
+type fakeAnswerProvider struct {
+	pieces []string
+}
+
+func (f *fakeAnswerProvider) GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error) {
+	return &types.AnswerResponse{Answer: strings.Join(f.pieces, ""), ModelID: "fake-model"}, nil
+}
+
+type fakeStreamingAnswerProvider struct {
+	fakeAnswerProvider
+}
+
+func (f *fakeStreamingAnswerProvider) StreamAnswer(ctx context.Context, request *types.AnswerRequest, onText func(text string) error) (*types.AnswerResponse, error) {
+	for _, piece := range f.pieces {
+		if err := onText(piece); err != nil {
+			return nil, err
+		}
+	}
+	return f.GenerateAnswer(ctx, request)
+}
+
+type panickingAnswerProvider struct{}
+
+func (f *panickingAnswerProvider) GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error) {
+	panic("model response was nil")
+}
+
+type fakeReferenceProcessor struct{}
+
+func (f *fakeReferenceProcessor) Process(ctx context.Context, gameID, responseText string, retrievedIDs map[string]bool, renderer references.FootnoteRenderer) (*references.ProcessedResponse, error) {
+	return &references.ProcessedResponse{
+		Response:   strings.ReplaceAll(responseText, " [[R1-NOISE,12]]", "¹"),
+		References: []*references.ReferenceInfo{{ID: 1, Title: "Noise", Page: "12"}},
+	}, nil
+}
+
+func TestStreamQuestion(t *testing.T) {
+	pieces := []string{"Roll a d10", " [[R1-NOISE,12]]", "."}
+
+	testCases := []struct {
+		description    string
+		answerProvider AnswerProvider
+		expectedDeltas int
+	}{
+		{
+			description:    "streaming provider sends each piece",
+			answerProvider: &fakeStreamingAnswerProvider{fakeAnswerProvider{pieces: pieces}},
+			expectedDeltas: 3,
+		},
+		{
+			description:    "buffered provider sends the whole answer",
+			answerProvider: &fakeAnswerProvider{pieces: pieces},
+			expectedDeltas: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			handler := NewQuestionHandler(&fakeKnowledgeProvider{}, tc.answerProvider, &fakeReferenceProcessor{}, QuestionHandlerOptions{})
+
+			var output bytes.Buffer
+			err := handler.StreamQuestion(context.Background(), &Request{GameName: "nemesis", Question: "How do noise rolls work?"}, &output)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			stream := output.String()
+			if got := strings.Count(stream, "event: delta\n"); got != tc.expectedDeltas {
+				t.Errorf("Expected %d delta events, Got %d", tc.expectedDeltas, got)
+			}
+
+			expectedDone := `event: done
+data: {"answer":"Roll a d10¹.","references":[{"id":1,"title":"Noise","section":"","page":"12","url":""}],"messageId":"`
+			if !strings.Contains(stream, expectedDone) {
+				t.Errorf("Expected stream to contain %q, Got %q", expectedDone, stream)
+			}
+		})
+	}
+}
+
+func TestStreamQuestionRecoversFromPanic(t *testing.T) {
+	handler := NewQuestionHandler(&fakeKnowledgeProvider{}, &panickingAnswerProvider{}, &fakeReferenceProcessor{}, QuestionHandlerOptions{})
+
+	var output bytes.Buffer
+	err := handler.StreamQuestion(context.Background(), &Request{GameName: "nemesis", Question: "How do noise rolls work?"}, &output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "event: error\ndata: {\"answer\":\"\",\"error\":\"Internal server error\"}\n\n"
+	if output.String() != expected {
+		t.Errorf("Expected stream %q, Got %q", expected, output.String())
+	}
+}
+
+func TestHandleStreamDecodesBase64Body(t *testing.T) {
+	body := `{"gameName":"nemesis","question":"How do noise rolls work?"}`
+
+	testCases := []struct {
+		description     string
+		body            string
+		isBase64Encoded bool
+		expectedStatus  int
+	}{
+		{
+			description:    "plain body",
+			body:           body,
+			expectedStatus: 200,
+		},
+		{
+			description:     "base64 encoded body",
+			body:            base64.StdEncoding.EncodeToString([]byte(body)),
+			isBase64Encoded: true,
+			expectedStatus:  200,
+		},
+		{
+			description:     "invalid base64 body",
+			body:            "not base64!",
+			isBase64Encoded: true,
+			expectedStatus:  400,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			handler := NewQuestionHandler(&fakeKnowledgeProvider{}, &fakeAnswerProvider{pieces: []string{"Roll a d10"}}, &fakeReferenceProcessor{}, QuestionHandlerOptions{})
+
+			response, err := handler.HandleStream(context.Background(), events.LambdaFunctionURLRequest{
+				Body:            tc.body,
+				IsBase64Encoded: tc.isBase64Encoded,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if response.StatusCode != tc.expectedStatus {
+				t.Errorf("Expected status %d, Got %d", tc.expectedStatus, response.StatusCode)
+			}
+
+			stream, err := io.ReadAll(response.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tc.expectedStatus == 200 && !strings.Contains(string(stream), "event: done\n") {
+				t.Errorf("Expected a done event, Got %q", stream)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type ImportRequest struct {
+	GameID string `json:"gameId"`
+}
+
+type ReferenceHandler struct {
+	manager  *references.Manager
+	adminKey string
+}
+
+// Every route requires adminKey, the handler rejects all requests when it is empty
+func NewReferenceHandler(manager *references.Manager, adminKey string) *ReferenceHandler {
+	return &ReferenceHandler{
+		manager:  manager,
+		adminKey: adminKey,
+	}
+}
+
+// Handle serves GET /references (game_id, limit and cursor query parameters), POST and PUT
+// /references with a reference body, DELETE /references (game_id and reference_id query
+// parameters) and POST /references/import with a game ID body
+func (h *ReferenceHandler) Handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if !utils.IsAdminRequest(request.Headers, h.adminKey) {
+		return utils.CreateErrorResponse(403, "Forbidden"), nil
+	}
+
+	var response interface{}
+	var err error
+	switch {
+	case request.HTTPMethod == "POST" && strings.HasSuffix(strings.TrimSuffix(request.Path, "/"), "/import"):
+		response, err = h.handleImport(ctx, request.Body)
+	case request.HTTPMethod == "GET":
+		response, err = h.handleList(ctx, request.QueryStringParameters)
+	case request.HTTPMethod == "POST":
+		response, err = h.handleSave(ctx, request.Body, h.manager.CreateReference)
+	case request.HTTPMethod == "PUT":
+		response, err = h.handleSave(ctx, request.Body, h.manager.UpdateReference)
+	case request.HTTPMethod == "DELETE":
+		params := request.QueryStringParameters
+		err = h.manager.DeleteReference(ctx, params["game_id"], params["reference_id"])
+		response = map[string]string{"message": "Reference deleted"}
+	default:
+		return utils.CreateErrorResponse(405, "Method not allowed"), nil
+	}
+
+	if err != nil {
+		if validationErr, ok := err.(*references.ValidationError); ok {
+			return utils.CreateErrorResponse(validationStatus(validationErr), validationErr.Message), nil
+		}
+		return utils.CreateErrorResponse(500, err.Error()), nil
+	}
+
+	return utils.CreateSuccessResponse(response)
+}
+
+func (h *ReferenceHandler) handleList(ctx context.Context, params map[string]string) (*references.ReferencePage, error) {
+	var limit int
+	if value := params["limit"]; value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			return nil, &references.ValidationError{Code: "INVALID_LIMIT", Message: fmt.Sprintf("invalid limit: %s", value)}
+		}
+	}
+
+	return h.manager.ListReferences(ctx, params["game_id"], limit, params["cursor"])
+}
+
+func (h *ReferenceHandler) handleSave(ctx context.Context, body string, save func(context.Context, *references.Reference) (*references.Reference, error)) (*references.Reference, error) {
+	var reference references.Reference
+	if err := json.Unmarshal([]byte(body), &reference); err != nil {
+		return nil, &references.ValidationError{Code: "INVALID_REQUEST", Message: fmt.Sprintf("invalid request format: %v", err)}
+	}
+
+	return save(ctx, &reference)
+}
+
+func (h *ReferenceHandler) handleImport(ctx context.Context, body string) (*references.ImportResult, error) {
+	var req ImportRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		return nil, &references.ValidationError{Code: "INVALID_REQUEST", Message: fmt.Sprintf("invalid request format: %v", err)}
+	}
+
+	return h.manager.ImportReferences(ctx, req.GameID)
+}
+
+func validationStatus(err *references.ValidationError) int {
+	switch err.Code {
+	case "REFERENCE_NOT_FOUND":
+		return 404
+	case "REFERENCE_EXISTS":
+		return 409
+	default:
+		return 400
+	}
+}
@@ -4,27 +4,45 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
 	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// chunkRecord is the stored form of a Chunk. Embeddings are written as a compact
+// binary attribute, while chunks written before that change still have their
+// embedding stored as a list of numbers and are decoded from that instead.
+type chunkRecord struct {
+	Chunk
+	EncodedEmbedding []byte    `dynamodbav:"embedding_bin,omitempty"`
+	LegacyEmbedding  []float64 `dynamodbav:"embedding,omitempty"`
+}
+
 type DynamoDBRepository struct {
-	dynamoDB       aws.DynamoDBClient
-	knowledgeTable string
+	dynamoDB        aws.DynamoDBClient
+	knowledgeTable  string
+	embeddingFormat string
 }
 
-func NewDynamoDBRepository(dynamoClient aws.DynamoDBClient, knowledgeTable string) *DynamoDBRepository {
-	log.Printf("Initializing knowledge repository with dynamoDB table: %s", knowledgeTable)
+func NewDynamoDBRepository(dynamoClient aws.DynamoDBClient, knowledgeTable string, embeddingFormat string) *DynamoDBRepository {
+	log.Printf("Initializing knowledge repository with dynamoDB table: %s, embedding format: %s", knowledgeTable, embeddingFormat)
 
 	return &DynamoDBRepository{
-		dynamoDB:       dynamoClient,
-		knowledgeTable: knowledgeTable,
+		dynamoDB:        dynamoClient,
+		knowledgeTable:  knowledgeTable,
+		embeddingFormat: embeddingFormat,
 	}
 }
 
 func (r *DynamoDBRepository) SaveKnowledgeChunk(ctx context.Context, chunk *Chunk) error {
-	err := r.dynamoDB.PutItem(ctx, r.knowledgeTable, chunk)
+	record, err := r.toRecord(chunk)
+	if err != nil {
+		return fmt.Errorf("failed to save knowledge chunk: %w", err)
+	}
+
+	err = r.dynamoDB.PutItem(ctx, r.knowledgeTable, record)
 	if err != nil {
 		return fmt.Errorf("failed to save knowledge chunk: %w", err)
 	}
@@ -34,18 +52,101 @@ func (r *DynamoDBRepository) SaveKnowledgeChunk(ctx context.Context, chunk *Chun
 }
 
 func (r *DynamoDBRepository) GetKnowledgeChunksByGame(ctx context.Context, gameName string) ([]*Chunk, error) {
-	var chunks []*Chunk
+	var records []*chunkRecord
 
 	err := r.dynamoDB.Query(ctx, r.knowledgeTable, nil,
 		"game_name = :game_name",
 		map[string]dynamoTypes.AttributeValue{
 			":game_name": &dynamoTypes.AttributeValueMemberS{Value: gameName},
-		}, &chunks)
+		}, &records)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get knowledge chunks: %w", err)
 	}
 
+	chunks := make([]*Chunk, 0, len(records))
+	legacy := 0
+	for _, record := range records {
+		chunk, err := r.fromRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode knowledge chunk %s: %w", record.ID, err)
+		}
+		if len(record.EncodedEmbedding) == 0 && len(record.LegacyEmbedding) > 0 {
+			legacy++
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if legacy > 0 {
+		log.Printf("WARNING: %d of %d chunks for game %s use the legacy embedding format and should be migrated",
+			legacy, len(chunks), gameName)
+	}
+
+	return chunks, nil
+}
+
+// textAttributes are every chunk attribute except the embedding
+var textAttributes = []string{"game_name", "chunk_id", "source_file", "content", "token_count",
+	"created_at", "updated_at", "embedding_model", "embedding_dimensions"}
+
+func (r *DynamoDBRepository) GetKnowledgeChunkTextsByGame(ctx context.Context, gameName string) ([]*Chunk, error) {
+	// Attribute names are aliased since some, such as content, are reserved words
+	names := make(map[string]string, len(textAttributes))
+	aliases := make([]string, len(textAttributes))
+	for i, attribute := range textAttributes {
+		aliases[i] = fmt.Sprintf("#a%d", i)
+		names[aliases[i]] = attribute
+	}
+
+	input := &aws.PageInput{
+		TableName:       r.knowledgeTable,
+		KeyCondition:    "#a0 = :game_name",
+		Projection:      strings.Join(aliases, ", "),
+		ExpressionNames: names,
+		ExpressionValues: map[string]dynamoTypes.AttributeValue{
+			":game_name": &dynamoTypes.AttributeValueMemberS{Value: gameName},
+		},
+	}
+
+	var chunks []*Chunk
+	for {
+		var page []*Chunk
+		cursor, err := r.dynamoDB.QueryPage(ctx, input, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get knowledge chunk texts: %w", err)
+		}
+		chunks = append(chunks, page...)
+
+		if cursor == "" {
+			return chunks, nil
+		}
+		input.Cursor = cursor
+	}
+}
+
+func (r *DynamoDBRepository) BatchGetKnowledgeChunks(ctx context.Context, gameName string, chunkIDs []string) ([]*Chunk, error) {
+	keys := make([]map[string]dynamoTypes.AttributeValue, len(chunkIDs))
+	for i, chunkID := range chunkIDs {
+		keys[i] = map[string]dynamoTypes.AttributeValue{
+			"game_name": &dynamoTypes.AttributeValueMemberS{Value: gameName},
+			"chunk_id":  &dynamoTypes.AttributeValueMemberS{Value: chunkID},
+		}
+	}
+
+	var records []*chunkRecord
+	if err := r.dynamoDB.BatchGetItems(ctx, r.knowledgeTable, keys, &records); err != nil {
+		return nil, fmt.Errorf("failed to batch get knowledge chunks: %w", err)
+	}
+
+	chunks := make([]*Chunk, 0, len(records))
+	for _, record := range records {
+		chunk, err := r.fromRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode knowledge chunk %s: %w", record.ID, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
 	return chunks, nil
 }
 
@@ -53,7 +154,11 @@ func (r *DynamoDBRepository) BatchSaveKnowledgeChunks(ctx context.Context, chunk
 	// Convert to []interface{} for generic batch write
 	items := make([]interface{}, len(chunks))
 	for i, chunk := range chunks {
-		items[i] = chunk
+		record, err := r.toRecord(chunk)
+		if err != nil {
+			return fmt.Errorf("failed to batch save knowledge chunks: %w", err)
+		}
+		items[i] = record
 	}
 
 	err := r.dynamoDB.BatchWriteItems(ctx, r.knowledgeTable, items)
@@ -64,3 +169,42 @@ func (r *DynamoDBRepository) BatchSaveKnowledgeChunks(ctx context.Context, chunk
 	log.Printf("Successfully batch saved %d knowledge chunks", len(chunks))
 	return nil
 }
+
+func (r *DynamoDBRepository) toRecord(chunk *Chunk) (*chunkRecord, error) {
+	encoded, err := utils.EncodeEmbedding(chunk.Vector(), r.embeddingFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embedding for chunk %s: %w", chunk.ID, err)
+	}
+
+	return &chunkRecord{
+		Chunk:            *chunk,
+		EncodedEmbedding: encoded,
+	}, nil
+}
+
+func (r *DynamoDBRepository) fromRecord(record *chunkRecord) (*Chunk, error) {
+	chunk := record.Chunk
+
+	switch {
+	case utils.IsQuantizedEmbedding(record.EncodedEmbedding):
+		// Kept in int8 so that vector search compares the compact form
+		quantized, err := utils.DecodeQuantizedEmbedding(record.EncodedEmbedding)
+		if err != nil {
+			return nil, err
+		}
+		chunk.Quantized = quantized
+	case len(record.EncodedEmbedding) > 0:
+		embedding, err := utils.DecodeEmbedding(record.EncodedEmbedding)
+		if err != nil {
+			return nil, err
+		}
+		chunk.Embedding = embedding
+	case len(record.LegacyEmbedding) > 0:
+		chunk.Embedding = make([]float32, len(record.LegacyEmbedding))
+		for i, value := range record.LegacyEmbedding {
+			chunk.Embedding[i] = float32(value)
+		}
+	}
+
+	return &chunk, nil
+}
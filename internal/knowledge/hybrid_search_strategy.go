@@ -5,14 +5,18 @@ import (
 	"log"
 	"math"
 	"sort"
-	"strings"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
-	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	"github.com/PhilNel/go-boardgame-assistant/internal/glossary"
+	"github.com/PhilNel/go-boardgame-assistant/internal/tokenizer"
 )
 
 type SearchStrategy interface {
-	Search(ctx context.Context, chunks []*Chunk, query string, queryEmbedding []float64) ([]*SearchResult, error)
+	// query is the text to search for, which may be a rewrite of the request's query.
+	// chunks are every chunk of the game, while vectorChunks are the chunks with an embedding
+	// that can be compared with queryEmbedding. trace is optional, the scores of every chunk
+	// considered are added to it when set.
+	Search(ctx context.Context, request *SearchRequest, chunks, vectorChunks []*Chunk, query string, queryEmbedding []float32, trace *RetrievalTrace) ([]*SearchResult, error)
 }
 
 // HybridSearchStrategy combines vector and keyword search results
@@ -20,24 +24,32 @@ type HybridSearchStrategy struct {
 	ragConfig     *config.RAG
 	vectorWeight  float64
 	keywordWeight float64
+	glossaryRepo  glossary.Repository
+	tokenizer     *tokenizer.Tokenizer
 }
 
-func NewHybridSearchStrategy(ragConfig *config.RAG, vectorWeight, keywordWeight float64) *HybridSearchStrategy {
+// glossaryRepo is optional, pass nil to score keywords without a glossary
+func NewHybridSearchStrategy(ragConfig *config.RAG, vectorWeight, keywordWeight float64, glossaryRepo glossary.Repository) *HybridSearchStrategy {
 	return &HybridSearchStrategy{
 		ragConfig:     ragConfig,
 		vectorWeight:  vectorWeight,
 		keywordWeight: keywordWeight,
+		glossaryRepo:  glossaryRepo,
+		tokenizer:     tokenizer.New(ragConfig.KeywordShortTerms),
 	}
 }
 
-func (h *HybridSearchStrategy) Search(ctx context.Context, chunks []*Chunk, query string, queryEmbedding []float64) ([]*SearchResult, error) {
-	vectorResults := h.performVectorSearch(chunks, queryEmbedding)
-	keywordResults := h.performKeywordSearch(chunks, query)
+func (h *HybridSearchStrategy) Search(ctx context.Context, request *SearchRequest, chunks, vectorChunks []*Chunk, query string, queryEmbedding []float32, trace *RetrievalTrace) ([]*SearchResult, error) {
+	// The vector provider has filled in the limits the request did not override
+	minSimilarity := *request.MinSimilarity
+	embedded := &embeddedQuery{values: queryEmbedding}
+	vectorResults := h.performVectorSearch(vectorChunks, embedded, minSimilarity)
+	keywordResults := h.performKeywordSearch(ctx, request.GameName, chunks, query)
 
 	combinedResults := h.combineResults(vectorResults, keywordResults)
 
 	var filteredResults []*SearchResult
-	minScore := h.ragConfig.MinSimilarity * 0.7
+	minScore := minSimilarity * 0.7
 
 	for _, result := range combinedResults {
 		if result.Similarity >= minScore {
@@ -48,15 +60,52 @@ func (h *HybridSearchStrategy) Search(ctx context.Context, chunks []*Chunk, quer
 	log.Printf("Hybrid search: vector=%d, keyword=%d, combined=%d, filtered=%d",
 		len(vectorResults), len(keywordResults), len(combinedResults), len(filteredResults))
 
+	if trace != nil {
+		trace.MinSimilarity = minSimilarity
+		trace.MinScore = minScore
+		h.traceResults(trace, query, embedded, vectorResults, keywordResults, combinedResults, minScore)
+	}
+
 	return filteredResults, nil
 }
 
-func (h *HybridSearchStrategy) performVectorSearch(chunks []*Chunk, queryEmbedding []float64) []*SearchResult {
+func (h *HybridSearchStrategy) traceResults(trace *RetrievalTrace, query string, embedded *embeddedQuery, vectorResults, keywordResults, combinedResults []*SearchResult, minScore float64) {
+	vectorMatches := make(map[string]bool, len(vectorResults))
+	for _, result := range vectorResults {
+		vectorMatches[result.Chunk.ID] = true
+	}
+
+	keywordScores := make(map[string]float64, len(keywordResults))
+	for _, result := range keywordResults {
+		keywordScores[result.Chunk.ID] = result.Similarity
+	}
+
+	for _, result := range combinedResults {
+		// Chunks only found by keyword may have been read without their embedding
+		var vectorScore float64
+		if result.Chunk.embeddingLength() == len(embedded.values) {
+			vectorScore = result.Chunk.similarity(embedded)
+		}
+
+		trace.Candidates = append(trace.Candidates, &ChunkTrace{
+			Query:        query,
+			ChunkID:      result.Chunk.ID,
+			SourceFile:   result.Chunk.SourceFile,
+			TokenCount:   result.Chunk.TokenCount,
+			VectorScore:  vectorScore,
+			VectorMatch:  vectorMatches[result.Chunk.ID],
+			KeywordScore: keywordScores[result.Chunk.ID],
+			FusedScore:   result.Similarity,
+			Kept:         result.Similarity >= minScore,
+		})
+	}
+}
+
+func (h *HybridSearchStrategy) performVectorSearch(chunks []*Chunk, query *embeddedQuery, minSimilarity float64) []*SearchResult {
 	var results []*SearchResult
-	minSimilarity := h.ragConfig.MinSimilarity
 
 	for _, chunk := range chunks {
-		similarity := utils.CosineSimilarity(queryEmbedding, chunk.Embedding)
+		similarity := chunk.similarity(query)
 
 		if similarity >= minSimilarity {
 			results = append(results, &SearchResult{
@@ -70,15 +119,39 @@ func (h *HybridSearchStrategy) performVectorSearch(chunks []*Chunk, queryEmbeddi
 	return results
 }
 
-func (h *HybridSearchStrategy) performKeywordSearch(chunks []*Chunk, query string) []*SearchResult {
-	queryTerms := h.tokenize(strings.ToLower(query))
+func (h *HybridSearchStrategy) performKeywordSearch(ctx context.Context, gameName string, chunks []*Chunk, query string) []*SearchResult {
+	gameGlossary := h.getGlossary(ctx, gameName)
+
+	queryTerms := h.tokenize(query, gameGlossary)
 	if len(queryTerms) == 0 {
 		return []*SearchResult{}
 	}
 
+	chunkTokens := make([][]string, len(chunks))
+	for i, chunk := range chunks {
+		chunkTokens[i] = h.tokenize(chunk.Content, gameGlossary)
+	}
+	docFreq := h.calculateDocumentFrequencies(chunkTokens)
+
+	// Match misspelt card and character names against the game's names. Other words are left
+	// alone since a valid word the rules don't use would be replaced by a different word.
+	var names map[string]int
+	for i, term := range queryTerms {
+		if _, exists := docFreq[term]; exists {
+			continue
+		}
+		if names == nil {
+			names = h.nameVocabulary(chunks, gameGlossary, docFreq)
+		}
+		if corrected := tokenizer.Correct(term, names); corrected != term {
+			log.Printf("Keyword search corrected '%s' to '%s'", term, corrected)
+			queryTerms[i] = corrected
+		}
+	}
+
 	var results []*SearchResult
-	for _, chunk := range chunks {
-		score := h.calculateTFIDFScore(queryTerms, chunk, chunks)
+	for i, chunk := range chunks {
+		score := h.calculateTFIDFScore(queryTerms, chunkTokens[i], docFreq, len(chunks))
 		if score > 0 {
 			results = append(results, &SearchResult{
 				Chunk:      chunk,
@@ -91,6 +164,46 @@ func (h *HybridSearchStrategy) performKeywordSearch(chunks []*Chunk, query strin
 	return results
 }
 
+// nameVocabulary returns the document frequencies of the tokens that are proper names in the
+// chunks or glossary terms, which are the terms players are most likely to misspell
+func (h *HybridSearchStrategy) nameVocabulary(chunks []*Chunk, gameGlossary *glossary.Glossary, docFreq map[string]int) map[string]int {
+	var words []string
+	for _, chunk := range chunks {
+		words = append(words, tokenizer.ProperNames(chunk.Content)...)
+	}
+	if gameGlossary != nil {
+		for term, canonical := range gameGlossary.Terms {
+			words = append(words, tokenizer.Words(term)...)
+			for _, rulebookTerm := range canonical {
+				words = append(words, tokenizer.Words(rulebookTerm)...)
+			}
+		}
+	}
+
+	names := make(map[string]int)
+	for _, token := range h.tokenizer.Tokens(words) {
+		if frequency, exists := docFreq[token]; exists {
+			names[token] = frequency
+		}
+	}
+	return names
+}
+
+// getGlossary is best effort, keyword search falls back to the raw tokens without a glossary
+func (h *HybridSearchStrategy) getGlossary(ctx context.Context, gameName string) *glossary.Glossary {
+	if h.glossaryRepo == nil {
+		return nil
+	}
+
+	gameGlossary, err := h.glossaryRepo.GetGlossary(ctx, gameName)
+	if err != nil {
+		log.Printf("WARNING: Failed to load glossary for game %s: %v", gameName, err)
+		return nil
+	}
+
+	return gameGlossary
+}
+
 func (h *HybridSearchStrategy) combineResults(vectorResults, keywordResults []*SearchResult) []*SearchResult {
 	scoreMap := make(map[string]*SearchResult)
 	vectorScores := h.normalizeScores(vectorResults)
@@ -161,34 +274,13 @@ func (h *HybridSearchStrategy) normalizeScores(results []*SearchResult) []float6
 }
 
 // Helper methods for keyword search
-func (h *HybridSearchStrategy) tokenize(text string) []string {
-	// Simple tokenization - split on whitespace and punctuation
-	words := strings.FieldsFunc(text, func(c rune) bool {
-		return !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'))
-	})
-
-	// Filter out short words and common stop words
-	var tokens []string
-	stopWords := map[string]bool{
-		"the": true, "a": true, "an": true, "and": true, "or": true, "but": true,
-		"in": true, "on": true, "at": true, "to": true, "for": true, "of": true,
-		"with": true, "by": true, "is": true, "are": true, "was": true, "were": true,
-		"be": true, "been": true, "have": true, "has": true, "had": true, "do": true,
-		"does": true, "did": true, "will": true, "would": true, "could": true, "should": true,
-	}
-
-	for _, word := range words {
-		word = strings.ToLower(word)
-		if len(word) > 2 && !stopWords[word] {
-			tokens = append(tokens, word)
-		}
-	}
-
-	return tokens
+func (h *HybridSearchStrategy) tokenize(text string, gameGlossary *glossary.Glossary) []string {
+	// Map slang to rulebook terms before stemming so glossary entries match the raw words
+	words := gameGlossary.Canonicalize(tokenizer.Words(text))
+	return h.tokenizer.Tokens(words)
 }
 
-func (h *HybridSearchStrategy) calculateTFIDFScore(queryTerms []string, chunk *Chunk, allChunks []*Chunk) float64 {
-	chunkTokens := h.tokenize(strings.ToLower(chunk.Content))
+func (h *HybridSearchStrategy) calculateTFIDFScore(queryTerms []string, chunkTokens []string, docFreq map[string]int, totalChunks int) float64 {
 	if len(chunkTokens) == 0 {
 		return 0
 	}
@@ -205,7 +297,7 @@ func (h *HybridSearchStrategy) calculateTFIDFScore(queryTerms []string, chunk *C
 	for _, term := range queryTerms {
 		tf := float64(termFreq[term]) / float64(len(chunkTokens))
 		if tf > 0 {
-			idf := h.calculateIDF(term, allChunks)
+			idf := h.calculateIDF(docFreq[term], totalChunks)
 			score += tf * idf
 		}
 	}
@@ -213,17 +305,25 @@ func (h *HybridSearchStrategy) calculateTFIDFScore(queryTerms []string, chunk *C
 	return score
 }
 
-func (h *HybridSearchStrategy) calculateIDF(term string, allChunks []*Chunk) float64 {
-	docsWithTerm := 0
-	for _, chunk := range allChunks {
-		if strings.Contains(strings.ToLower(chunk.Content), term) {
-			docsWithTerm++
+// calculateDocumentFrequencies counts how many chunks contain each token
+func (h *HybridSearchStrategy) calculateDocumentFrequencies(chunkTokens [][]string) map[string]int {
+	docFreq := make(map[string]int)
+	for _, tokens := range chunkTokens {
+		seen := make(map[string]bool)
+		for _, token := range tokens {
+			if !seen[token] {
+				seen[token] = true
+				docFreq[token]++
+			}
 		}
 	}
+	return docFreq
+}
 
+func (h *HybridSearchStrategy) calculateIDF(docsWithTerm, totalChunks int) float64 {
 	if docsWithTerm == 0 {
 		return 0
 	}
 
-	return math.Log(float64(len(allChunks)) / float64(docsWithTerm))
+	return math.Log(float64(totalChunks) / float64(docsWithTerm))
 }
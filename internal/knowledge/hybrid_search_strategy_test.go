@@ -0,0 +1,48 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+)
+
+func TestKeywordSearchCorrectsNames(t *testing.T) {
+	chunks := []*Chunk{
+		{ID: "lurker", Content: "The Lurker moves to a random room."},
+		{ID: "vanish", Content: "Vanish the token once it is used."},
+		{ID: "noise", Content: "Roll for noise in every corridor."},
+	}
+	strategy := NewHybridSearchStrategy(&config.RAG{}, 0.5, 0.5, nil)
+
+	testCases := []struct {
+		description string
+		query       string
+		expectedIDs []string
+	}{
+		{
+			description: "Misspelt name is corrected",
+			query:       "where does the lurkr go",
+			expectedIDs: []string{"lurker"},
+		},
+		{
+			description: "Words that are not names are left alone",
+			query:       "can I banish",
+			expectedIDs: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			results := strategy.performKeywordSearch(context.Background(), "nemesis", chunks, tc.query)
+
+			var ids []string
+			for _, result := range results {
+				ids = append(ids, result.Chunk.ID)
+			}
+			if len(ids) != len(tc.expectedIDs) || (len(ids) > 0 && ids[0] != tc.expectedIDs[0]) {
+				t.Errorf("Expected chunks %v, Got %v", tc.expectedIDs, ids)
+			}
+		})
+	}
+}
@@ -9,22 +9,33 @@ import (
 	"time"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
+	"github.com/PhilNel/go-boardgame-assistant/internal/vectorindex"
 )
 
+// embeddingBatchSize is the number of files embedded per request, with job progress updated after each batch
+const embeddingBatchSize = 10
+
 type Processor struct {
 	fileProvider      FileProvider
 	embeddingProvider EmbeddingProvider
 	knowledgeRepo     KnowledgeRepository
 	statusRepo        StatusRepository
+	indexRepo         IndexRepository
+	referenceRepo     ReferenceRepository
 	config            *config.RAG
 }
 
-func NewProcessor(fileProvider FileProvider, embeddingProvider EmbeddingProvider, knowledgeRepo KnowledgeRepository, statusRepo StatusRepository, cfg *config.RAG) *Processor {
+// indexRepo and referenceRepo are optional, pass nil to skip building vector indexes and
+// saving the references defined in the rule files
+func NewProcessor(fileProvider FileProvider, embeddingProvider EmbeddingProvider, knowledgeRepo KnowledgeRepository, statusRepo StatusRepository, indexRepo IndexRepository, referenceRepo ReferenceRepository, cfg *config.RAG) *Processor {
 	return &Processor{
 		fileProvider:      fileProvider,
 		embeddingProvider: embeddingProvider,
 		knowledgeRepo:     knowledgeRepo,
 		statusRepo:        statusRepo,
+		indexRepo:         indexRepo,
+		referenceRepo:     referenceRepo,
 		config:            cfg,
 	}
 }
@@ -50,8 +61,10 @@ func (p *Processor) ProcessGame(ctx context.Context, gameName string) (*Processi
 
 	log.Printf("Processing %d files for game: %s", len(supportedFiles), gameName)
 
-	var chunks []*Chunk
-	processed := 0
+	var filePaths []string
+	var contents []string
+	var definitions []*references.Reference
+	definedIn := make(map[string]string)
 
 	for _, file := range supportedFiles {
 		log.Printf("Processing file: %s", file)
@@ -62,38 +75,62 @@ func (p *Processor) ProcessGame(ctx context.Context, gameName string) (*Processi
 			continue
 		}
 
-		chunk, err := p.createKnowledgeChunk(ctx, gameName, file, string(content))
+		// Reference definitions are stored in the references table rather than embedded
+		fileDefinitions, body, err := references.ExtractDefinitions(gameName, string(content))
 		if err != nil {
-			log.Printf("Failed to create chunk for file %s: %v", file, err)
-			continue
+			return p.failJob(ctx, jobID, gameName, "read references in "+file, err)
+		}
+		for _, reference := range fileDefinitions {
+			if previousFile, exists := definedIn[reference.ReferenceID]; exists {
+				return p.failJob(ctx, jobID, gameName, "read references in "+file, fmt.Errorf("reference %s is also defined in %s", reference.ReferenceID, previousFile))
+			}
+			definedIn[reference.ReferenceID] = file
+		}
+		definitions = append(definitions, fileDefinitions...)
+
+		filePaths = append(filePaths, file)
+		contents = append(contents, body)
+	}
+
+	undefined, err := p.checkReferences(ctx, gameName, contents, definitions)
+	if err != nil {
+		return p.failJob(ctx, jobID, gameName, "check references", err)
+	}
+
+	if p.referenceRepo != nil && len(definitions) > 0 {
+		if err := p.referenceRepo.BatchSaveReferences(ctx, definitions); err != nil {
+			return p.failJob(ctx, jobID, gameName, "store references", err)
 		}
+	}
 
-		chunks = append(chunks, chunk)
-		processed++
+	// Files are embedded in batches so the provider can group and cache requests, while a
+	// file that fails to embed is skipped like a file that fails to read
+	var chunks []*Chunk
+	for start := 0; start < len(contents); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(contents))
 
-		// Update progress periodically
-		if processed%5 == 0 || processed == len(supportedFiles) {
-			if err := p.statusRepo.UpdateJobProgress(ctx, jobID, processed); err != nil {
-				log.Printf("Failed to update job progress: %v", err)
+		embeddings := p.embedBatch(ctx, filePaths[start:end], contents[start:end])
+		for i, embedding := range embeddings {
+			if embedding != nil {
+				chunks = append(chunks, p.createKnowledgeChunk(gameName, filePaths[start+i], contents[start+i], embedding))
 			}
 		}
+
+		if err := p.statusRepo.UpdateJobProgress(ctx, jobID, len(chunks)); err != nil {
+			log.Printf("Failed to update job progress: %v", err)
+		}
 	}
+	processed := len(chunks)
 
 	// Batch store chunks
 	if len(chunks) > 0 {
 		if err := p.knowledgeRepo.BatchSaveKnowledgeChunks(ctx, chunks); err != nil {
-			// Fail the job and return error
-			if failErr := p.statusRepo.FailJob(ctx, jobID, gameName, fmt.Sprintf("Failed to store chunks: %v", err)); failErr != nil {
-				log.Printf("Failed to update job failure: %v", failErr)
-			}
-			return &ProcessingResult{
-				JobID:   jobID,
-				Status:  "failed",
-				Message: fmt.Sprintf("Failed to store chunks: %v", err),
-			}, fmt.Errorf("failed to store chunks: %w", err)
+			return p.failJob(ctx, jobID, gameName, "store chunks", err)
 		}
 	}
 
+	p.buildVectorIndex(ctx, gameName, chunks)
+
 	if err := p.statusRepo.CompleteJob(ctx, jobID, gameName, processed, len(supportedFiles)); err != nil {
 		log.Printf("Failed to update job completion: %v", err)
 	}
@@ -101,17 +138,199 @@ func (p *Processor) ProcessGame(ctx context.Context, gameName string) (*Processi
 	log.Printf("Knowledge processing completed for game: %s, processed: %d/%d",
 		gameName, processed, len(supportedFiles))
 
+	return &ProcessingResult{
+		JobID:               jobID,
+		GameName:            gameName,
+		Status:              "completed",
+		Message:             "Knowledge processing completed successfully",
+		Processed:           processed,
+		Total:               len(supportedFiles),
+		References:          len(definitions),
+		UndefinedReferences: undefined,
+	}, nil
+}
+
+// MigrateEmbeddings rewrites every stored chunk for a game so that embeddings
+// written in the legacy number list format are stored in the compact format
+func (p *Processor) MigrateEmbeddings(ctx context.Context, gameName string) (*ProcessingResult, error) {
+	log.Printf("Starting embedding migration for game: %s", gameName)
+
+	chunks, err := p.knowledgeRepo.GetKnowledgeChunksByGame(ctx, gameName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge chunks: %w", err)
+	}
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no knowledge chunks found for game: %s", gameName)
+	}
+
+	jobID, err := p.statusRepo.CreateProcessingJob(ctx, gameName, len(chunks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create processing job: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		chunk.UpdatedAt = time.Now().Unix()
+	}
+
+	if err := p.knowledgeRepo.BatchSaveKnowledgeChunks(ctx, chunks); err != nil {
+		return p.failJob(ctx, jobID, gameName, "migrate chunks", err)
+	}
+
+	if err := p.statusRepo.CompleteJob(ctx, jobID, gameName, len(chunks), len(chunks)); err != nil {
+		log.Printf("Failed to update job completion: %v", err)
+	}
+
+	log.Printf("Embedding migration completed for game: %s, migrated: %d", gameName, len(chunks))
+
 	return &ProcessingResult{
 		JobID:     jobID,
 		GameName:  gameName,
 		Status:    "completed",
-		Message:   "Knowledge processing completed successfully",
-		Processed: processed,
-		Total:     len(supportedFiles),
+		Message:   "Embedding migration completed successfully",
+		Processed: len(chunks),
+		Total:     len(chunks),
 	}, nil
 }
 
-func (p *Processor) createKnowledgeChunk(ctx context.Context, gameName, filePath, content string) (*Chunk, error) {
+// ReembedGame recreates the embeddings of a game's stored chunks that were created
+// with a different embedding model or dimension than the one currently configured.
+// With force set every chunk is re-embedded.
+func (p *Processor) ReembedGame(ctx context.Context, gameName string, force bool) (*ProcessingResult, error) {
+	modelID := p.embeddingProvider.GetModelID()
+	dimensions := p.embeddingProvider.GetDimensions()
+	log.Printf("Starting re-embedding for game: %s with model: %s (%d dimensions)", gameName, modelID, dimensions)
+
+	chunks, err := p.knowledgeRepo.GetKnowledgeChunksByGame(ctx, gameName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge chunks: %w", err)
+	}
+
+	var outdated []*Chunk
+	for _, chunk := range chunks {
+		isCurrent := chunk.EmbeddingModel == modelID && chunk.IsEmbeddingCompatible(modelID, dimensions)
+		if force || !isCurrent {
+			outdated = append(outdated, chunk)
+		}
+	}
+
+	if len(outdated) == 0 {
+		log.Printf("All %d chunks for game %s already use %s", len(chunks), gameName, modelID)
+		return &ProcessingResult{
+			GameName: gameName,
+			Status:   "completed",
+			Message:  "All chunks already use the configured embedding model",
+			Total:    len(chunks),
+		}, nil
+	}
+
+	jobID, err := p.statusRepo.CreateProcessingJob(ctx, gameName, len(outdated))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create processing job: %w", err)
+	}
+
+	contents := make([]string, len(outdated))
+	for i, chunk := range outdated {
+		contents[i] = chunk.Content
+	}
+
+	embeddings, err := p.embeddingProvider.CreateEmbeddings(ctx, contents)
+	if err != nil {
+		return p.failJob(ctx, jobID, gameName, "create embeddings", err)
+	}
+
+	for i, chunk := range outdated {
+		chunk.Embedding = embeddings[i]
+		chunk.Quantized = nil
+		chunk.EmbeddingModel = modelID
+		chunk.EmbeddingDimensions = len(embeddings[i])
+		chunk.UpdatedAt = time.Now().Unix()
+	}
+
+	if err := p.knowledgeRepo.BatchSaveKnowledgeChunks(ctx, outdated); err != nil {
+		return p.failJob(ctx, jobID, gameName, "store chunks", err)
+	}
+
+	// The index is built over every chunk, so rebuild it from the full updated set
+	p.buildVectorIndex(ctx, gameName, chunks)
+
+	if err := p.statusRepo.CompleteJob(ctx, jobID, gameName, len(outdated), len(outdated)); err != nil {
+		log.Printf("Failed to update job completion: %v", err)
+	}
+
+	log.Printf("Re-embedding completed for game: %s, re-embedded: %d", gameName, len(outdated))
+
+	return &ProcessingResult{
+		JobID:     jobID,
+		GameName:  gameName,
+		Status:    "completed",
+		Message:   "Re-embedding completed successfully",
+		Processed: len(outdated),
+		Total:     len(outdated),
+	}, nil
+}
+
+// checkReferences returns the reference IDs cited in the contents that are neither defined in
+// the rule files nor already saved. Undefined references fail the job when configured to.
+func (p *Processor) checkReferences(ctx context.Context, gameName string, contents []string, definitions []*references.Reference) ([]string, error) {
+	if p.referenceRepo == nil {
+		if len(definitions) > 0 {
+			log.Printf("WARNING: Ignoring %d reference definitions for game %s without a references table", len(definitions), gameName)
+		}
+		return nil, nil
+	}
+
+	undefined, err := references.FindUndefinedReferences(ctx, p.referenceRepo, gameName, contents, definitions)
+	if err != nil {
+		return nil, err
+	}
+	if len(undefined) == 0 {
+		return nil, nil
+	}
+
+	if p.config.UndefinedRefs == "fail" {
+		return nil, fmt.Errorf("rule files cite undefined references: %s", strings.Join(undefined, ", "))
+	}
+
+	log.Printf("WARNING: Rule files for game %s cite %d undefined references: %v", gameName, len(undefined), undefined)
+	return undefined, nil
+}
+
+// embedBatch embeds the contents in one request, falling back to one request per file when
+// the batch fails. Files that still fail are logged and left as nil embeddings.
+func (p *Processor) embedBatch(ctx context.Context, filePaths, contents []string) [][]float32 {
+	embeddings, err := p.embeddingProvider.CreateEmbeddings(ctx, contents)
+	if err == nil {
+		return embeddings
+	}
+	log.Printf("Failed to create embeddings for %d files, retrying each file: %v", len(contents), err)
+
+	embeddings = make([][]float32, len(contents))
+	for i, content := range contents {
+		fileEmbeddings, err := p.embeddingProvider.CreateEmbeddings(ctx, []string{content})
+		if err != nil {
+			log.Printf("Failed to create embedding for file %s: %v", filePaths[i], err)
+			continue
+		}
+		embeddings[i] = fileEmbeddings[0]
+	}
+	return embeddings
+}
+
+// failJob marks the job as failed, where step describes what failed, e.g. "store chunks"
+func (p *Processor) failJob(ctx context.Context, jobID, gameName, step string, err error) (*ProcessingResult, error) {
+	message := fmt.Sprintf("Failed to %s: %v", step, err)
+	if failErr := p.statusRepo.FailJob(ctx, jobID, gameName, message); failErr != nil {
+		log.Printf("Failed to update job failure: %v", failErr)
+	}
+	return &ProcessingResult{
+		JobID:   jobID,
+		Status:  "failed",
+		Message: message,
+	}, fmt.Errorf("failed to %s: %w", step, err)
+}
+
+func (p *Processor) createKnowledgeChunk(gameName, filePath, content string, embedding []float32) *Chunk {
 	// Count tokens (simple estimation: ~4 chars per token)
 	tokenCount := len(content) / 4
 	if tokenCount > p.config.MaxChunkTokens {
@@ -119,25 +338,49 @@ func (p *Processor) createKnowledgeChunk(ctx context.Context, gameName, filePath
 			filePath, tokenCount, p.config.MaxChunkTokens)
 	}
 
-	embedding, err := p.embeddingProvider.CreateEmbedding(ctx, content)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create embedding: %w", err)
-	}
-
 	chunkID := p.generateChunkID(gameName, filePath)
 
 	chunk := &Chunk{
-		ID:         chunkID,
-		GameName:   gameName,
-		SourceFile: filePath,
-		Content:    content,
-		Embedding:  embedding,
-		TokenCount: tokenCount,
-		CreatedAt:  time.Now().Unix(),
-		UpdatedAt:  time.Now().Unix(),
+		ID:                  chunkID,
+		GameName:            gameName,
+		SourceFile:          filePath,
+		Content:             content,
+		Embedding:           embedding,
+		TokenCount:          tokenCount,
+		CreatedAt:           time.Now().Unix(),
+		UpdatedAt:           time.Now().Unix(),
+		EmbeddingModel:      p.embeddingProvider.GetModelID(),
+		EmbeddingDimensions: len(embedding),
+	}
+
+	return chunk
+}
+
+// buildVectorIndex is best effort, the question handler falls back to exact search without an index
+func (p *Processor) buildVectorIndex(ctx context.Context, gameName string, chunks []*Chunk) {
+	if p.indexRepo == nil || len(chunks) < p.config.IndexMinChunks {
+		return
+	}
+
+	ids := make([]string, len(chunks))
+	embeddings := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		ids[i] = chunk.ID
+		embeddings[i] = chunk.Vector()
+	}
+
+	index, err := vectorindex.Build(gameName, ids, embeddings, p.config.IndexLists)
+	if err != nil {
+		log.Printf("WARNING: Failed to build vector index for game %s: %v", gameName, err)
+		return
+	}
+
+	if err := p.indexRepo.SaveIndex(ctx, index); err != nil {
+		log.Printf("WARNING: Failed to save vector index for game %s: %v", gameName, err)
+		return
 	}
 
-	return chunk, nil
+	log.Printf("Built vector index for game %s over %d chunks", gameName, len(chunks))
 }
 
 func (p *Processor) filterSupportedFiles(files []string) []string {
@@ -0,0 +1,263 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
+)
+
+type fakeFileProvider struct {
+	files map[string]string
+}
+
+func (f *fakeFileProvider) GetFiles(ctx context.Context, gameName string) ([]string, error) {
+	var files []string
+	for file := range f.files {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func (f *fakeFileProvider) GetFileContent(ctx context.Context, filePath string) ([]byte, error) {
+	return []byte(f.files[filePath]), nil
+}
+
+type fakeEmbeddingProvider struct{}
+
+func (f *fakeEmbeddingProvider) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return []float32{1, 0}, nil
+}
+
+// CreateEmbeddings fails for the whole batch when any text is oversized
+func (f *fakeEmbeddingProvider) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "oversized") {
+			return nil, fmt.Errorf("text %d is too long", i)
+		}
+		embeddings[i] = []float32{1, 0}
+	}
+	return embeddings, nil
+}
+
+func (f *fakeEmbeddingProvider) GetModelID() string { return "fake-embedding" }
+
+func (f *fakeEmbeddingProvider) GetDimensions() int { return 2 }
+
+type fakeKnowledgeRepository struct {
+	chunks    []*Chunk
+	batchGets [][]string
+}
+
+func (r *fakeKnowledgeRepository) SaveKnowledgeChunk(ctx context.Context, chunk *Chunk) error {
+	r.chunks = append(r.chunks, chunk)
+	return nil
+}
+
+func (r *fakeKnowledgeRepository) GetKnowledgeChunksByGame(ctx context.Context, gameName string) ([]*Chunk, error) {
+	return r.chunks, nil
+}
+
+func (r *fakeKnowledgeRepository) GetKnowledgeChunkTextsByGame(ctx context.Context, gameName string) ([]*Chunk, error) {
+	texts := make([]*Chunk, len(r.chunks))
+	for i, chunk := range r.chunks {
+		text := *chunk
+		text.Embedding = nil
+		texts[i] = &text
+	}
+	return texts, nil
+}
+
+func (r *fakeKnowledgeRepository) BatchGetKnowledgeChunks(ctx context.Context, gameName string, chunkIDs []string) ([]*Chunk, error) {
+	r.batchGets = append(r.batchGets, chunkIDs)
+	var found []*Chunk
+	for _, chunk := range r.chunks {
+		if slices.Contains(chunkIDs, chunk.ID) {
+			found = append(found, chunk)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeKnowledgeRepository) BatchSaveKnowledgeChunks(ctx context.Context, chunks []*Chunk) error {
+	r.chunks = append(r.chunks, chunks...)
+	return nil
+}
+
+type fakeStatusRepository struct {
+	progress []int
+}
+
+func (r *fakeStatusRepository) CreateProcessingJob(ctx context.Context, gameName string, totalFiles int) (string, error) {
+	return "job", nil
+}
+
+func (r *fakeStatusRepository) UpdateJobProgress(ctx context.Context, jobID string, progress int) error {
+	r.progress = append(r.progress, progress)
+	return nil
+}
+
+func (r *fakeStatusRepository) CompleteJob(ctx context.Context, jobID string, gameName string, processed, total int) error {
+	return nil
+}
+
+func (r *fakeStatusRepository) FailJob(ctx context.Context, jobID string, gameName string, errorMsg string) error {
+	return nil
+}
+
+type fakeReferenceRepository struct {
+	saved map[string]*references.Reference
+}
+
+func (r *fakeReferenceRepository) BatchGetReferences(ctx context.Context, gameID string, referenceIDs []string) (map[string]*references.Reference, error) {
+	found := make(map[string]*references.Reference)
+	for _, referenceID := range referenceIDs {
+		if reference, exists := r.saved[referenceID]; exists {
+			found[referenceID] = reference
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeReferenceRepository) BatchSaveReferences(ctx context.Context, refs []*references.Reference) error {
+	for _, reference := range refs {
+		r.saved[reference.ReferenceID] = reference
+	}
+	return nil
+}
+
+func TestProcessGameReferences(t *testing.T) {
+	slime := "---\nreferences:\n  - referenceId: R1-SLIME\n    title: Slime\n---\n# Slime\nSlimed characters [[R1-SLIME,17]]."
+
+	testCases := []struct {
+		description       string
+		files             map[string]string
+		undefinedRefs     string
+		expectedErr       bool
+		expectedUndefined []string
+		expectedSaved     []string
+	}{
+		{
+			description: "Definitions are saved and stripped from the chunks",
+			files: map[string]string{
+				"games/nemesis/slime.md": slime,
+				"games/nemesis/fire.md":  "# Fire\nFire spreads [[R2-FIRE]].",
+			},
+			undefinedRefs: "warn",
+			expectedSaved: []string{"R1-SLIME", "R2-FIRE"},
+		},
+		{
+			description: "Undefined citations are reported",
+			files: map[string]string{
+				"games/nemesis/slime.md": slime,
+				"games/nemesis/flee.md":  "# Flee\nIntruders flee [[R9-FLEE]].",
+			},
+			undefinedRefs:     "warn",
+			expectedUndefined: []string{"R9-FLEE"},
+			expectedSaved:     []string{"R1-SLIME", "R2-FIRE"},
+		},
+		{
+			description: "Undefined citations fail the job",
+			files: map[string]string{
+				"games/nemesis/slime.md": slime,
+				"games/nemesis/flee.md":  "# Flee\nIntruders flee [[R9-FLEE]].",
+			},
+			undefinedRefs: "fail",
+			expectedErr:   true,
+			expectedSaved: []string{"R2-FIRE"},
+		},
+		{
+			description: "Reference defined in two files fails the job",
+			files: map[string]string{
+				"games/nemesis/slime.md": slime,
+				"games/nemesis/more.md":  strings.Replace(slime, "# Slime", "# More slime", 1),
+			},
+			undefinedRefs: "warn",
+			expectedErr:   true,
+			expectedSaved: []string{"R2-FIRE"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			knowledgeRepo := &fakeKnowledgeRepository{}
+			referenceRepo := &fakeReferenceRepository{
+				saved: map[string]*references.Reference{"R2-FIRE": {GameID: "nemesis", ReferenceID: "R2-FIRE", Title: "Fire"}},
+			}
+			cfg := &config.RAG{MaxChunkTokens: 500, UndefinedRefs: tc.undefinedRefs}
+			processor := NewProcessor(&fakeFileProvider{files: tc.files}, &fakeEmbeddingProvider{}, knowledgeRepo, &fakeStatusRepository{}, nil, referenceRepo, cfg)
+
+			result, err := processor.ProcessGame(context.Background(), "nemesis")
+
+			var saved []string
+			for _, referenceID := range []string{"R1-SLIME", "R2-FIRE", "R9-FLEE"} {
+				if _, exists := referenceRepo.saved[referenceID]; exists {
+					saved = append(saved, referenceID)
+				}
+			}
+			if fmt.Sprint(saved) != fmt.Sprint(tc.expectedSaved) {
+				t.Errorf("Expected saved references %v, Got %v", tc.expectedSaved, saved)
+			}
+
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected the job to fail, Got %+v", result)
+				}
+				if len(knowledgeRepo.chunks) != 0 {
+					t.Errorf("Expected no chunks to be stored, Got %d", len(knowledgeRepo.chunks))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if fmt.Sprint(result.UndefinedReferences) != fmt.Sprint(tc.expectedUndefined) {
+				t.Errorf("Expected undefined references %v, Got %v", tc.expectedUndefined, result.UndefinedReferences)
+			}
+			for _, chunk := range knowledgeRepo.chunks {
+				if strings.Contains(chunk.Content, "referenceId") {
+					t.Errorf("Expected the definitions to be stripped from %s, Got %q", chunk.SourceFile, chunk.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessGameSkipsFilesThatFailToEmbed(t *testing.T) {
+	files := make(map[string]string)
+	for i := range 12 {
+		files[fmt.Sprintf("games/nemesis/rule%02d.md", i)] = fmt.Sprintf("# Rule %d", i)
+	}
+	files["games/nemesis/rule03.md"] = "# Rule 3, which is oversized"
+
+	knowledgeRepo := &fakeKnowledgeRepository{}
+	statusRepo := &fakeStatusRepository{}
+	processor := NewProcessor(&fakeFileProvider{files: files}, &fakeEmbeddingProvider{}, knowledgeRepo, statusRepo, nil, nil, &config.RAG{MaxChunkTokens: 500})
+
+	result, err := processor.ProcessGame(context.Background(), "nemesis")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Processed != 11 || result.Total != 12 {
+		t.Errorf("Expected 11 of 12 files processed, Got %d of %d", result.Processed, result.Total)
+	}
+	if len(knowledgeRepo.chunks) != 11 {
+		t.Errorf("Expected 11 chunks to be stored, Got %d", len(knowledgeRepo.chunks))
+	}
+	for _, chunk := range knowledgeRepo.chunks {
+		if strings.Contains(chunk.Content, "oversized") {
+			t.Errorf("Expected the oversized file to be skipped, Got chunk for %s", chunk.SourceFile)
+		}
+	}
+	if fmt.Sprint(statusRepo.progress) != "[9 11]" {
+		t.Errorf("Expected progress [9 11] after each batch, Got %v", statusRepo.progress)
+	}
+}
@@ -1,24 +1,88 @@
 package knowledge
 
-import "context"
+import (
+	"context"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/references"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+	"github.com/PhilNel/go-boardgame-assistant/internal/vectorindex"
+)
 
 type Chunk struct {
 	ID         string    `json:"id" dynamodbav:"chunk_id"`
 	GameName   string    `json:"game_name" dynamodbav:"game_name"`
 	SourceFile string    `json:"source_file" dynamodbav:"source_file"`
 	Content    string    `json:"content" dynamodbav:"content"`
-	Embedding  []float64 `json:"embedding" dynamodbav:"embedding"`
-	TokenCount int       `json:"token_count" dynamodbav:"token_count"`
-	CreatedAt  int64     `json:"created_at" dynamodbav:"created_at"`
-	UpdatedAt  int64     `json:"updated_at" dynamodbav:"updated_at"`
+	Embedding  []float32 `json:"embedding" dynamodbav:"-"`
+	// Quantized is set instead of Embedding for chunks stored in the int8 format
+	Quantized  *utils.QuantizedEmbedding `json:"-" dynamodbav:"-"`
+	TokenCount int                       `json:"token_count" dynamodbav:"token_count"`
+	CreatedAt  int64                     `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt  int64                     `json:"updated_at" dynamodbav:"updated_at"`
+
+	// Chunks created before these were recorded have them empty
+	EmbeddingModel      string `json:"embedding_model,omitempty" dynamodbav:"embedding_model,omitempty"`
+	EmbeddingDimensions int    `json:"embedding_dimensions,omitempty" dynamodbav:"embedding_dimensions,omitempty"`
+}
+
+// IsEmbeddingCompatible reports whether the chunk's embedding can be compared with
+// embeddings from the given model. Chunks without a recorded model predate model
+// tracking and are only checked on their dimensions.
+func (c *Chunk) IsEmbeddingCompatible(modelID string, dimensions int) bool {
+	if c.EmbeddingModel != "" && c.EmbeddingModel != modelID {
+		return false
+	}
+	return c.embeddingLength() == dimensions
+}
+
+// Vector returns the chunk's embedding as floats, converting quantised embeddings back
+func (c *Chunk) Vector() []float32 {
+	if c.Embedding == nil && c.Quantized != nil {
+		return c.Quantized.Dequantize()
+	}
+	return c.Embedding
+}
+
+// similarity is the cosine similarity between the query and the chunk's embedding. Quantised
+// embeddings are compared in int8 against the quantised query.
+func (c *Chunk) similarity(query *embeddedQuery) float64 {
+	if c.Embedding == nil && c.Quantized != nil {
+		return utils.CosineSimilarityInt8(query.quantized(), c.Quantized)
+	}
+	return utils.CosineSimilarity(query.values, c.Embedding)
+}
+
+func (c *Chunk) embeddingLength() int {
+	if c.Embedding == nil && c.Quantized != nil {
+		return len(c.Quantized.Values)
+	}
+	return len(c.Embedding)
+}
+
+// embeddedQuery is a query's embedding, quantised on first use for comparing against
+// chunks stored in the int8 format
+type embeddedQuery struct {
+	values          []float32
+	quantizedValues *utils.QuantizedEmbedding
+}
+
+func (q *embeddedQuery) quantized() *utils.QuantizedEmbedding {
+	if q.quantizedValues == nil {
+		q.quantizedValues = utils.QuantizeEmbedding(q.values)
+	}
+	return q.quantizedValues
 }
 
+// SearchRequest is a search for knowledge. MinSimilarity, MaxTokens and TopK are optional
+// overrides, nil uses the RAG configuration. They are pointers so that a minimum similarity
+// of zero, which keeps every chunk, can be asked for.
 type SearchRequest struct {
-	GameName      string  `json:"game_name"`
-	Query         string  `json:"query"`
-	MinSimilarity float64 `json:"min_similarity"`
-	MaxTokens     int     `json:"max_tokens"`
-	TopK          int     `json:"top_k"`
+	GameName      string   `json:"game_name"`
+	Query         string   `json:"query"`
+	MinSimilarity *float64 `json:"min_similarity,omitempty"`
+	MaxTokens     *int     `json:"max_tokens,omitempty"`
+	TopK          *int     `json:"top_k,omitempty"`
 }
 
 type SearchResult struct {
@@ -26,9 +90,56 @@ type SearchResult struct {
 	Similarity float64 `json:"similarity"`
 }
 
+// RetrievedKnowledge is the context found for a question
+type RetrievedKnowledge struct {
+	// Content is the combined text of the selected chunks that is sent to the model
+	Content string
+	// Results are the selected chunks, best match first
+	Results []*SearchResult
+}
+
+// RetrievalTrace records how the knowledge for a question was chosen, for debugging bad answers
+type RetrievalTrace struct {
+	Queries []string `json:"queries"`
+	// ChunksSearched is the number of chunks searched by keyword
+	ChunksSearched int `json:"chunks_searched"`
+	// VectorChunks is the number of chunks with a compatible embedding compared against
+	// the queries, which is fewer than ChunksSearched when the vector index is used
+	VectorChunks int `json:"vector_chunks"`
+	// MinSimilarity is the vector similarity a chunk needs to count as a vector match
+	MinSimilarity float64 `json:"min_similarity"`
+	// MinScore is the fused score a chunk needs to be kept
+	MinScore   float64       `json:"min_score"`
+	MaxTokens  int           `json:"max_tokens"`
+	TopK       int           `json:"top_k"`
+	Candidates []*ChunkTrace `json:"candidates"`
+	Selected   []*ChunkTrace `json:"selected"`
+	// SelectedTokens is the number of tokens of knowledge sent to the model
+	SelectedTokens int `json:"selected_tokens"`
+}
+
+// ChunkTrace holds the scores of a chunk found by the vector or keyword search for one query
+type ChunkTrace struct {
+	Query      string `json:"query,omitempty"`
+	ChunkID    string `json:"chunk_id"`
+	SourceFile string `json:"source_file"`
+	TokenCount int    `json:"token_count"`
+	// VectorScore and KeywordScore are the raw cosine similarity and TF-IDF score
+	VectorScore  float64 `json:"vector_score"`
+	VectorMatch  bool    `json:"vector_match"`
+	KeywordScore float64 `json:"keyword_score"`
+	// FusedScore is the weighted combination of the normalised scores
+	FusedScore float64 `json:"fused_score"`
+	Kept       bool    `json:"kept"`
+}
+
 type KnowledgeRepository interface {
 	SaveKnowledgeChunk(ctx context.Context, chunk *Chunk) error
 	GetKnowledgeChunksByGame(ctx context.Context, gameName string) ([]*Chunk, error)
+	// GetKnowledgeChunkTextsByGame returns the game's chunks without their embeddings
+	GetKnowledgeChunkTextsByGame(ctx context.Context, gameName string) ([]*Chunk, error)
+	// BatchGetKnowledgeChunks returns the chunks with their embeddings, leaving out IDs without a chunk
+	BatchGetKnowledgeChunks(ctx context.Context, gameName string, chunkIDs []string) ([]*Chunk, error)
 	BatchSaveKnowledgeChunks(ctx context.Context, chunks []*Chunk) error
 }
 
@@ -38,7 +149,28 @@ type FileProvider interface {
 }
 
 type EmbeddingProvider interface {
-	CreateEmbedding(ctx context.Context, text string) ([]float64, error)
+	// CreateEmbedding embeds a search query
+	CreateEmbedding(ctx context.Context, text string) ([]float32, error)
+	// CreateEmbeddings embeds rule text to be stored and searched against
+	CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+	GetModelID() string
+	GetDimensions() int
+}
+
+type IndexRepository interface {
+	SaveIndex(ctx context.Context, index *vectorindex.Index) error
+	GetIndex(ctx context.Context, gameName string) (*vectorindex.Index, error)
+}
+
+// ReferenceRepository stores the references defined in the rule files
+type ReferenceRepository interface {
+	BatchGetReferences(ctx context.Context, gameID string, referenceIDs []string) (map[string]*references.Reference, error)
+	BatchSaveReferences(ctx context.Context, references []*references.Reference) error
+}
+
+type QueryRewriter interface {
+	// history holds the earlier turns of the conversation, oldest first, and may be empty
+	RewriteQuery(ctx context.Context, gameName, query string, history []types.ConversationTurn) ([]string, error)
 }
 
 type StatusRepository interface {
@@ -55,4 +187,8 @@ type ProcessingResult struct {
 	Message   string `json:"message"`
 	Processed int    `json:"processed"`
 	Total     int    `json:"total"`
+	// References is the number of references defined in the rule files
+	References int `json:"references,omitempty"`
+	// UndefinedReferences are the reference IDs cited in the rule files that are not defined
+	UndefinedReferences []string `json:"undefined_references,omitempty"`
 }
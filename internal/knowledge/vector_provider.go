@@ -8,6 +8,9 @@ import (
 	"strings"
 
 	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/glossary"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+	"github.com/PhilNel/go-boardgame-assistant/internal/vectorindex"
 )
 
 // When no knowledge chunks meet the similarity threshold
@@ -27,62 +30,309 @@ type VectorProvider struct {
 	embeddingProvider EmbeddingProvider
 	ragConfig         *config.RAG
 	searchStrategy    SearchStrategy
+	queryRewriter     QueryRewriter
+	indexRepo         IndexRepository
 }
 
-func NewVectorProvider(knowledgeRepo KnowledgeRepository, embeddingProvider EmbeddingProvider, ragConfig *config.RAG) *VectorProvider {
-	searchStrategy := NewHybridSearchStrategy(ragConfig, ragConfig.VectorWeight, ragConfig.KeywordWeight)
+// queryRewriter, glossaryRepo and indexRepo are optional and may be nil
+func NewVectorProvider(knowledgeRepo KnowledgeRepository, embeddingProvider EmbeddingProvider, queryRewriter QueryRewriter, glossaryRepo glossary.Repository, indexRepo IndexRepository, ragConfig *config.RAG) *VectorProvider {
+	searchStrategy := NewHybridSearchStrategy(ragConfig, ragConfig.VectorWeight, ragConfig.KeywordWeight, glossaryRepo)
 
 	return &VectorProvider{
 		knowledgeRepo:     knowledgeRepo,
 		embeddingProvider: embeddingProvider,
 		ragConfig:         ragConfig,
 		searchStrategy:    searchStrategy,
+		queryRewriter:     queryRewriter,
+		indexRepo:         indexRepo,
 	}
 }
 
-func (v *VectorProvider) GetKnowledge(ctx context.Context, gameName string, query string) (string, error) {
-	queryEmbedding, err := v.embeddingProvider.CreateEmbedding(ctx, query)
-	if err != nil {
-		return "", fmt.Errorf("failed to create query embedding: %w", err)
+// GetKnowledge finds the knowledge for the request's query, using the RAG configuration
+// for any limits the request leaves unset. history holds the earlier turns of the
+// conversation and is used to resolve follow-up questions.
+func (v *VectorProvider) GetKnowledge(ctx context.Context, request *SearchRequest, history []types.ConversationTurn) (*RetrievedKnowledge, error) {
+	return v.retrieve(ctx, v.withDefaults(request), history, nil)
+}
+
+// GetKnowledgeWithTrace also returns how each chunk was scored and selected. The trace
+// is returned with a NoRelevantKnowledgeError too, since it shows why nothing was kept.
+func (v *VectorProvider) GetKnowledgeWithTrace(ctx context.Context, request *SearchRequest, history []types.ConversationTurn) (*RetrievedKnowledge, *RetrievalTrace, error) {
+	request = v.withDefaults(request)
+	trace := &RetrievalTrace{
+		MaxTokens: *request.MaxTokens,
+		TopK:      *request.TopK,
 	}
 
-	chunks, err := v.knowledgeRepo.GetKnowledgeChunksByGame(ctx, gameName)
-	if err != nil {
-		return "", fmt.Errorf("failed to get knowledge chunks: %w", err)
+	retrieved, err := v.retrieve(ctx, request, history, trace)
+	return retrieved, trace, err
+}
+
+// withDefaults fills in the limits the request does not override, so that every limit
+// is set on the returned request
+func (v *VectorProvider) withDefaults(request *SearchRequest) *SearchRequest {
+	minSimilarity, maxTokens, topK := v.ragConfig.MinSimilarity, v.ragConfig.MaxTokens, v.ragConfig.TopK
+
+	resolved := *request
+	if resolved.MinSimilarity == nil {
+		resolved.MinSimilarity = &minSimilarity
+	}
+	if resolved.MaxTokens == nil {
+		resolved.MaxTokens = &maxTokens
+	}
+	if resolved.TopK == nil {
+		resolved.TopK = &topK
 	}
+	return &resolved
+}
+
+func (v *VectorProvider) retrieve(ctx context.Context, request *SearchRequest, history []types.ConversationTurn, trace *RetrievalTrace) (*RetrievedKnowledge, error) {
+	gameName, query := request.GameName, request.Query
 
-	log.Printf("Retrieved %d chunks for game '%s'", len(chunks), gameName)
+	queries := v.expandQuery(ctx, gameName, query, history)
+	queryEmbeddings := make([][]float32, len(queries))
+	for i, searchQuery := range queries {
+		queryEmbedding, err := v.embeddingProvider.CreateEmbedding(ctx, searchQuery)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create query embedding: %w", err)
+		}
+		queryEmbeddings[i] = queryEmbedding
+	}
 
-	results, err := v.searchStrategy.Search(ctx, chunks, query, queryEmbedding)
+	chunks, vectorChunks, err := v.loadChunks(ctx, gameName, queryEmbeddings)
 	if err != nil {
-		return "", fmt.Errorf("search strategy failed: %w", err)
+		return nil, err
+	}
+
+	log.Printf("Retrieved %d chunks for game '%s', %d with embeddings", len(chunks), gameName, len(vectorChunks))
+
+	vectorChunks = v.filterCompatibleChunks(gameName, vectorChunks)
+
+	if trace != nil {
+		trace.Queries = queries
+		trace.ChunksSearched = len(chunks)
+		trace.VectorChunks = len(vectorChunks)
 	}
 
+	var resultSets [][]*SearchResult
+	for i, searchQuery := range queries {
+		queryResults, err := v.searchStrategy.Search(ctx, request, chunks, vectorChunks, searchQuery, queryEmbeddings[i], trace)
+		if err != nil {
+			return nil, fmt.Errorf("search strategy failed: %w", err)
+		}
+
+		resultSets = append(resultSets, queryResults)
+	}
+
+	results := v.fuseResults(resultSets)
+
 	if len(results) == 0 {
-		return "", &NoRelevantKnowledgeError{
+		return nil, &NoRelevantKnowledgeError{
 			GameName:      gameName,
 			Query:         query,
-			MinSimilarity: v.ragConfig.MinSimilarity,
+			MinSimilarity: *request.MinSimilarity,
 			ChunksFound:   len(chunks),
 		}
 	}
 
-	selectedResults := v.selectChunksWithinTokenBudget(results)
+	selectedResults := v.selectChunksWithinTokenBudget(v.selectTopK(results, *request.TopK), *request.MaxTokens)
 	combinedKnowledge := v.buildCombinedKnowledge(selectedResults, query)
 
 	log.Printf("Search for '%s': found %d chunks, selected %d chunks with %d total tokens",
 		query, len(results), len(selectedResults), v.calculateTotalTokens(selectedResults))
 
-	return combinedKnowledge, nil
+	if trace != nil {
+		for _, result := range selectedResults {
+			trace.Selected = append(trace.Selected, &ChunkTrace{
+				ChunkID:    result.Chunk.ID,
+				SourceFile: result.Chunk.SourceFile,
+				TokenCount: result.Chunk.TokenCount,
+				FusedScore: result.Similarity,
+				Kept:       true,
+			})
+		}
+		trace.SelectedTokens = v.calculateTotalTokens(selectedResults)
+	}
+
+	return &RetrievedKnowledge{
+		Content: combinedKnowledge,
+		Results: selectedResults,
+	}, nil
+}
+
+// loadChunks returns every chunk of the game for keyword search, and the chunks with their
+// embeddings for vector search. Games with a vector index only have the embeddings of the
+// chunks in the clusters closest to the queries read, along with chunks added since the
+// index was built. Games without an index, and index failures, read every embedding.
+func (v *VectorProvider) loadChunks(ctx context.Context, gameName string, queryEmbeddings [][]float32) ([]*Chunk, []*Chunk, error) {
+	index := v.getIndex(ctx, gameName)
+	if index == nil {
+		chunks, err := v.knowledgeRepo.GetKnowledgeChunksByGame(ctx, gameName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get knowledge chunks: %w", err)
+		}
+		return chunks, chunks, nil
+	}
+
+	chunks, err := v.knowledgeRepo.GetKnowledgeChunkTextsByGame(ctx, gameName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get knowledge chunks: %w", err)
+	}
+
+	isCandidate := make(map[string]bool)
+	for _, queryEmbedding := range queryEmbeddings {
+		for _, id := range index.Candidates(queryEmbedding, v.ragConfig.IndexProbes) {
+			isCandidate[id] = true
+		}
+	}
+
+	var candidateIDs []string
+	unindexed := 0
+	for _, chunk := range chunks {
+		if isCandidate[chunk.ID] {
+			candidateIDs = append(candidateIDs, chunk.ID)
+		} else if !index.Contains(chunk.ID) {
+			candidateIDs = append(candidateIDs, chunk.ID)
+			unindexed++
+		}
+	}
+
+	if len(candidateIDs) == 0 {
+		log.Printf("WARNING: Vector index for game %s returned no candidates, using exact search", gameName)
+		chunks, err := v.knowledgeRepo.GetKnowledgeChunksByGame(ctx, gameName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get knowledge chunks: %w", err)
+		}
+		return chunks, chunks, nil
+	}
+
+	vectorChunks, err := v.knowledgeRepo.BatchGetKnowledgeChunks(ctx, gameName, candidateIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get candidate chunks: %w", err)
+	}
+
+	log.Printf("Vector index selected %d of %d chunks (%d not in index)", len(candidateIDs), len(chunks), unindexed)
+	return chunks, vectorChunks, nil
+}
+
+// getIndex returns nil when the game has no index or is too small to use it
+func (v *VectorProvider) getIndex(ctx context.Context, gameName string) *vectorindex.Index {
+	if v.indexRepo == nil {
+		return nil
+	}
+
+	index, err := v.indexRepo.GetIndex(ctx, gameName)
+	if err != nil {
+		log.Printf("WARNING: Failed to load vector index for game %s, using exact search: %v", gameName, err)
+		return nil
+	}
+	if index == nil || index.Size() < v.ragConfig.IndexMinChunks {
+		return nil
+	}
+
+	return index
+}
+
+// filterCompatibleChunks drops chunks embedded with a different model or dimension from
+// vector search, since their similarity scores against the query embedding are meaningless.
+// They are still found by keyword search, which is all that is left while a game is re-embedded
+// after a model change.
+func (v *VectorProvider) filterCompatibleChunks(gameName string, chunks []*Chunk) []*Chunk {
+	modelID := v.embeddingProvider.GetModelID()
+	dimensions := v.embeddingProvider.GetDimensions()
+
+	compatible := make([]*Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.IsEmbeddingCompatible(modelID, dimensions) {
+			compatible = append(compatible, chunk)
+		}
+	}
+
+	incompatible := len(chunks) - len(compatible)
+	if incompatible == 0 {
+		return chunks
+	}
+
+	log.Printf("WARNING: Excluding %d of %d chunks for game %s not embedded with %s (%d dimensions) from vector search",
+		incompatible, len(chunks), gameName, modelID, dimensions)
+
+	if len(compatible) == 0 {
+		log.Printf("WARNING: No chunks for game %s were embedded with %s, searching by keyword only until the game is re-embedded",
+			gameName, modelID)
+	}
+
+	return compatible
+}
+
+// expandQuery returns the original query followed by any rewritten queries. Follow-up
+// questions are also searched together with the previous question, so that a question
+// like "what about on the second turn?" still finds the rules being discussed.
+// Rewriting is best effort, failures fall back to the original queries only.
+func (v *VectorProvider) expandQuery(ctx context.Context, gameName, query string, history []types.ConversationTurn) []string {
+	queries := []string{query}
+	if len(history) > 0 {
+		queries = append(queries, history[len(history)-1].Question+" "+query)
+	}
+
+	if v.queryRewriter == nil {
+		return queries
+	}
+
+	rewritten, err := v.queryRewriter.RewriteQuery(ctx, gameName, query, history)
+	if err != nil {
+		log.Printf("WARNING: Query rewriting failed, using original query only: %v", err)
+		return queries
+	}
+
+	queries = append(queries, rewritten...)
+	log.Printf("Rewritten queries for '%s': %q", query, rewritten)
+
+	return queries
+}
+
+// fuseResults merges the results of each query, keeping the best score per chunk
+func (v *VectorProvider) fuseResults(resultSets [][]*SearchResult) []*SearchResult {
+	if len(resultSets) == 1 {
+		return resultSets[0]
+	}
+
+	bestResults := make(map[string]*SearchResult)
+	for _, resultSet := range resultSets {
+		for _, result := range resultSet {
+			existing, exists := bestResults[result.Chunk.ID]
+			if !exists || result.Similarity > existing.Similarity {
+				bestResults[result.Chunk.ID] = result
+			}
+		}
+	}
+
+	fused := make([]*SearchResult, 0, len(bestResults))
+	for _, result := range bestResults {
+		fused = append(fused, result)
+	}
+
+	log.Printf("Fused %d result sets into %d unique chunks", len(resultSets), len(fused))
+	return fused
 }
 
-func (v *VectorProvider) selectChunksWithinTokenBudget(results []*SearchResult) []*SearchResult {
+// selectTopK keeps the k best results, best first
+func (v *VectorProvider) selectTopK(results []*SearchResult, k int) []*SearchResult {
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Similarity > results[j].Similarity
 	})
+
+	if len(results) > k {
+		log.Printf("Keeping the top %d of %d chunks", k, len(results))
+		results = results[:k]
+	}
+
+	return results
+}
+
+// selectChunksWithinTokenBudget expects results sorted best first
+func (v *VectorProvider) selectChunksWithinTokenBudget(results []*SearchResult, maxTokens int) []*SearchResult {
 	var selected []*SearchResult
 	totalTokens := 0
-	maxTokens := v.ragConfig.MaxTokens
 
 	for _, result := range results {
 		// Check if adding this chunk would exceed the token budget
@@ -0,0 +1,192 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/vectorindex"
+)
+
+func TestWithDefaults(t *testing.T) {
+	provider := &VectorProvider{ragConfig: &config.RAG{MinSimilarity: 0.65, MaxTokens: 2000, TopK: 10}}
+
+	newRequest := func(minSimilarity float64, maxTokens, topK int) *SearchRequest {
+		return &SearchRequest{GameName: "nemesis", Query: "noise", MinSimilarity: &minSimilarity, MaxTokens: &maxTokens, TopK: &topK}
+	}
+
+	testCases := []struct {
+		description string
+		request     *SearchRequest
+		expected    *SearchRequest
+	}{
+		{
+			description: "No overrides",
+			request:     &SearchRequest{GameName: "nemesis", Query: "noise"},
+			expected:    newRequest(0.65, 2000, 10),
+		},
+		{
+			description: "All overrides",
+			request:     newRequest(0.4, 500, 2),
+			expected:    newRequest(0.4, 500, 2),
+		},
+		{
+			description: "Zero minimum similarity is kept",
+			request:     newRequest(0, 500, 2),
+			expected:    newRequest(0, 500, 2),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			resolved := provider.withDefaults(tc.request)
+			if !reflect.DeepEqual(resolved, tc.expected) {
+				t.Errorf("Expected %+v, Got %+v", *tc.expected, *resolved)
+			}
+		})
+	}
+}
+
+func TestSelectTopKWithinTokenBudget(t *testing.T) {
+	provider := &VectorProvider{}
+	newResult := func(id string, score float64, tokens int) *SearchResult {
+		return &SearchResult{Chunk: &Chunk{ID: id, TokenCount: tokens}, Similarity: score}
+	}
+
+	testCases := []struct {
+		description string
+		topK        int
+		maxTokens   int
+		expectedIDs []string
+	}{
+		{description: "Top k limits the chunks", topK: 2, maxTokens: 1000, expectedIDs: []string{"a", "b"}},
+		{description: "Token budget limits the chunks", topK: 10, maxTokens: 250, expectedIDs: []string{"a", "b"}},
+		{description: "Everything fits", topK: 10, maxTokens: 1000, expectedIDs: []string{"a", "b", "c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			results := []*SearchResult{newResult("c", 0.5, 100), newResult("a", 0.9, 100), newResult("b", 0.7, 100)}
+
+			selected := provider.selectChunksWithinTokenBudget(provider.selectTopK(results, tc.topK), tc.maxTokens)
+
+			if len(selected) != len(tc.expectedIDs) {
+				t.Fatalf("Expected %d chunks, Got %d", len(tc.expectedIDs), len(selected))
+			}
+			for i, id := range tc.expectedIDs {
+				if selected[i].Chunk.ID != id {
+					t.Errorf("Expected chunk %s at %d, Got %s", id, i, selected[i].Chunk.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestRetrieveKeepsIncompatibleChunksForKeywords(t *testing.T) {
+	knowledgeRepo := &fakeKnowledgeRepository{chunks: []*Chunk{
+		{ID: "a", Content: "Intruders move", EmbeddingModel: "fake-embedding", Embedding: []float32{1, 0}, TokenCount: 10},
+		{ID: "b", Content: "Fire spreads", EmbeddingModel: "fake-embedding", Embedding: []float32{0, 1}, TokenCount: 10},
+		// Embedded with the previous model during a migration
+		{ID: "c", Content: "Roll for noise", EmbeddingModel: "old-embedding", Embedding: []float32{1, 0}, TokenCount: 10},
+	}}
+	ragConfig := &config.RAG{MinSimilarity: 0.5, MaxTokens: 1000, TopK: 10, VectorWeight: 0.5, KeywordWeight: 0.5}
+	provider := NewVectorProvider(knowledgeRepo, &fakeEmbeddingProvider{}, nil, nil, nil, ragConfig)
+
+	retrieved, trace, err := provider.GetKnowledgeWithTrace(context.Background(), &SearchRequest{GameName: "nemesis", Query: "noise"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if trace.ChunksSearched != 3 || trace.VectorChunks != 2 {
+		t.Errorf("Expected 3 chunks searched and 2 vector chunks, Got %d and %d", trace.ChunksSearched, trace.VectorChunks)
+	}
+
+	var ids []string
+	for _, result := range retrieved.Results {
+		ids = append(ids, result.Chunk.ID)
+	}
+	if !slices.Contains(ids, "a") || !slices.Contains(ids, "c") || slices.Contains(ids, "b") {
+		t.Errorf("Expected chunks a and c, Got %v", ids)
+	}
+	for _, candidate := range trace.Candidates {
+		if candidate.ChunkID == "c" && candidate.VectorMatch {
+			t.Errorf("Expected chunk c to only match by keyword")
+		}
+	}
+}
+
+func TestRetrieveFallsBackToKeywordsAfterModelChange(t *testing.T) {
+	knowledgeRepo := &fakeKnowledgeRepository{chunks: []*Chunk{
+		{ID: "a", Content: "Intruders move", EmbeddingModel: "old-embedding", Embedding: []float32{1, 0}, TokenCount: 10},
+		{ID: "b", Content: "Roll for noise", EmbeddingModel: "old-embedding", Embedding: []float32{0, 1}, TokenCount: 10},
+	}}
+	ragConfig := &config.RAG{MinSimilarity: 0.5, MaxTokens: 1000, TopK: 10, VectorWeight: 0.5, KeywordWeight: 0.5}
+	provider := NewVectorProvider(knowledgeRepo, &fakeEmbeddingProvider{}, nil, nil, nil, ragConfig)
+
+	retrieved, trace, err := provider.GetKnowledgeWithTrace(context.Background(), &SearchRequest{GameName: "nemesis", Query: "noise"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if trace.VectorChunks != 0 {
+		t.Errorf("Expected no vector chunks, Got %d", trace.VectorChunks)
+	}
+	if len(retrieved.Results) != 1 || retrieved.Results[0].Chunk.ID != "b" {
+		t.Errorf("Expected chunk b from keyword search, Got %v", retrieved.Results)
+	}
+}
+
+type fakeIndexRepository struct {
+	index *vectorindex.Index
+}
+
+func (r *fakeIndexRepository) SaveIndex(ctx context.Context, index *vectorindex.Index) error {
+	r.index = index
+	return nil
+}
+
+func (r *fakeIndexRepository) GetIndex(ctx context.Context, gameName string) (*vectorindex.Index, error) {
+	return r.index, nil
+}
+
+func TestRetrieveWithVectorIndex(t *testing.T) {
+	newChunk := func(id, content, model string, embedding []float32) *Chunk {
+		return &Chunk{ID: id, Content: content, EmbeddingModel: model, Embedding: embedding, TokenCount: 10}
+	}
+	chunks := []*Chunk{
+		newChunk("a", "Intruders move", "fake-embedding", []float32{1, 0}),
+		newChunk("b", "Intruders attack", "fake-embedding", []float32{0.9, 0.1}),
+		newChunk("c", "Fire spreads", "fake-embedding", []float32{0, 1}),
+		newChunk("d", "Slime sticks", "fake-embedding", []float32{0.1, 0.9}),
+		// Added since the index was built
+		newChunk("e", "Doors close", "fake-embedding", []float32{0, 1}),
+		// Embedded with the previous model during a migration
+		newChunk("f", "Roll for noise", "old-embedding", []float32{1, 0}),
+	}
+
+	index, err := vectorindex.Build("nemesis", []string{"a", "b", "c", "d"},
+		[][]float32{{1, 0}, {0.9, 0.1}, {0, 1}, {0.1, 0.9}}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	knowledgeRepo := &fakeKnowledgeRepository{chunks: chunks}
+	ragConfig := &config.RAG{MinSimilarity: 0.5, MaxTokens: 1000, TopK: 10, VectorWeight: 0.5, KeywordWeight: 0.5, IndexMinChunks: 4, IndexProbes: 1}
+	provider := NewVectorProvider(knowledgeRepo, &fakeEmbeddingProvider{}, nil, nil, &fakeIndexRepository{index: index}, ragConfig)
+
+	retrieved, err := provider.GetKnowledge(context.Background(), &SearchRequest{GameName: "nemesis", Query: "noise"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(knowledgeRepo.batchGets) != 1 || fmt.Sprint(knowledgeRepo.batchGets[0]) != "[a b e f]" {
+		t.Errorf("Expected the embeddings of [a b e f] to be read, Got %v", knowledgeRepo.batchGets)
+	}
+
+	if len(retrieved.Results) == 0 {
+		t.Errorf("Expected chunks to be retrieved")
+	}
+}
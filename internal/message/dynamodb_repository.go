@@ -0,0 +1,49 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoDBRepository struct {
+	dynamoDB     aws.DynamoDBClient
+	answersTable string
+}
+
+func NewDynamoDBRepository(dynamoClient aws.DynamoDBClient, answersTable string) *DynamoDBRepository {
+	log.Printf("Initializing message repository with dynamoDB table: %s", answersTable)
+
+	return &DynamoDBRepository{
+		dynamoDB:     dynamoClient,
+		answersTable: answersTable,
+	}
+}
+
+func (r *DynamoDBRepository) SaveMessage(ctx context.Context, message *Message) error {
+	if err := r.dynamoDB.PutItem(ctx, r.answersTable, message); err != nil {
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+	return nil
+}
+
+func (r *DynamoDBRepository) GetMessage(ctx context.Context, messageID string) (*Message, error) {
+	key := map[string]dynamoTypes.AttributeValue{
+		"message_id": &dynamoTypes.AttributeValueMemberS{Value: messageID},
+	}
+
+	var message Message
+	err := r.dynamoDB.GetItem(ctx, r.answersTable, key, &message)
+	if errors.Is(err, aws.ErrItemNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return &message, nil
+}
@@ -0,0 +1,32 @@
+package message
+
+import "context"
+
+// RetrievedChunk is a knowledge chunk that was given to the model for an answer
+type RetrievedChunk struct {
+	ChunkID    string  `json:"chunk_id" dynamodbav:"chunk_id"`
+	SourceFile string  `json:"source_file" dynamodbav:"source_file"`
+	Score      float64 `json:"score" dynamodbav:"score"`
+}
+
+// Message is an answer given by the question handler, stored so that feedback
+// can be joined to exactly what was answered and how
+type Message struct {
+	MessageID       string           `json:"message_id" dynamodbav:"message_id"`
+	GameName        string           `json:"game_name" dynamodbav:"game_name"`
+	ConversationID  string           `json:"conversation_id,omitempty" dynamodbav:"conversation_id,omitempty"`
+	Question        string           `json:"question" dynamodbav:"question"`
+	Answer          string           `json:"answer" dynamodbav:"answer"`
+	RetrievedChunks []RetrievedChunk `json:"retrieved_chunks,omitempty" dynamodbav:"retrieved_chunks,omitempty"`
+	PromptTemplate  string           `json:"prompt_template,omitempty" dynamodbav:"prompt_template,omitempty"`
+	ModelID         string           `json:"model_id,omitempty" dynamodbav:"model_id,omitempty"`
+	// InvalidCitations is the number of cited references that were not in the retrieved chunks
+	InvalidCitations int   `json:"invalid_citations,omitempty" dynamodbav:"invalid_citations,omitempty"`
+	CreatedAt        int64 `json:"created_at" dynamodbav:"created_at"`
+}
+
+type Repository interface {
+	SaveMessage(ctx context.Context, message *Message) error
+	// GetMessage returns nil when no message exists for the ID
+	GetMessage(ctx context.Context, messageID string) (*Message, error)
+}
@@ -0,0 +1,101 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+)
+
+type HTTPClient struct {
+	httpClient       *http.Client
+	baseURL          string
+	apiKey           string
+	modelID          string
+	embeddingModelID string
+}
+
+func NewHTTPClient(config *config.OpenAI) *HTTPClient {
+	log.Printf("Initializing OpenAI compatible client with base URL: %s, model: %s, embedding model: %s",
+		config.BaseURL, config.ModelID, config.EmbeddingModelID)
+
+	return &HTTPClient{
+		httpClient:       &http.Client{Timeout: time.Duration(config.TimeoutSeconds) * time.Second},
+		baseURL:          strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:           config.APIKey,
+		modelID:          config.ModelID,
+		embeddingModelID: config.EmbeddingModelID,
+	}
+}
+
+func (c *HTTPClient) CreateChatCompletion(ctx context.Context, request *ChatRequest) (*ChatResponse, error) {
+	var response ChatResponse
+	if err := c.post(ctx, "/chat/completions", request, &response); err != nil {
+		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) CreateEmbeddings(ctx context.Context, request *EmbeddingRequest) (*EmbeddingResponse, error) {
+	var response EmbeddingResponse
+	if err := c.post(ctx, "/embeddings", request, &response); err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) GetModelID() string {
+	return c.modelID
+}
+
+func (c *HTTPClient) GetEmbeddingModelID() string {
+	return c.embeddingModelID
+}
+
+func (c *HTTPClient) post(ctx context.Context, path string, request, response interface{}) error {
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(requestBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResponse.Body.Close()
+
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		var apiError errorResponse
+		if err := json.Unmarshal(responseBody, &apiError); err == nil && apiError.Error.Message != "" {
+			return fmt.Errorf("server returned status %d: %s", httpResponse.StatusCode, apiError.Error.Message)
+		}
+		return fmt.Errorf("server returned status %d: %s", httpResponse.StatusCode, string(responseBody))
+	}
+
+	if err := json.Unmarshal(responseBody, response); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
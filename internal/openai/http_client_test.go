@@ -0,0 +1,106 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *HTTPClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewHTTPClient(&config.OpenAI{
+		BaseURL:          server.URL + "/v1/",
+		APIKey:           "test-key",
+		ModelID:          "local-chat",
+		EmbeddingModelID: "local-embed",
+		TimeoutSeconds:   5,
+	})
+}
+
+func TestCreateChatCompletion(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Expected path /v1/chat/completions, Got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Expected bearer token, Got %q", r.Header.Get("Authorization"))
+		}
+
+		var request ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if request.Model != "local-chat" || len(request.Messages) != 2 {
+			t.Errorf("Unexpected request: %+v", request)
+		}
+
+		json.NewEncoder(w).Encode(ChatResponse{
+			Choices: []ChatChoice{{Message: ChatMessage{Role: "assistant", Content: "Roll two dice."}}},
+		})
+	})
+
+	response, err := client.CreateChatCompletion(context.Background(), &ChatRequest{
+		Model: "local-chat",
+		Messages: []ChatMessage{
+			{Role: "system", Content: "You answer rules questions."},
+			{Role: "user", Content: "How do I attack?"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := response.Choices[0].Message.Content; got != "Roll two dice." {
+		t.Errorf("Expected %q, Got %q", "Roll two dice.", got)
+	}
+}
+
+func TestCreateEmbeddings(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		response := EmbeddingResponse{}
+		for i := range request.Input {
+			response.Data = append(response.Data, EmbeddingData{Index: i, Embedding: []float32{float32(i), 1}})
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+
+	response, err := client.CreateEmbeddings(context.Background(), &EmbeddingRequest{
+		Model: "local-embed",
+		Input: []string{"first", "second"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(response.Data) != 2 || response.Data[1].Embedding[0] != 1 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"model not loaded","type":"invalid_request_error"}}`))
+	})
+
+	_, err := client.CreateEmbeddings(context.Background(), &EmbeddingRequest{Model: "missing", Input: []string{"text"}})
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	expected := "failed to create embeddings: server returned status 400: model not loaded"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, Got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,58 @@
+package openai
+
+import "context"
+
+// Client talks to any server implementing the OpenAI chat completions and embeddings
+// APIs, such as llama.cpp server, vLLM or Ollama
+type Client interface {
+	CreateChatCompletion(ctx context.Context, request *ChatRequest) (*ChatResponse, error)
+	CreateEmbeddings(ctx context.Context, request *EmbeddingRequest) (*EmbeddingResponse, error)
+	GetModelID() string
+	GetEmbeddingModelID() string
+}
+
+type ChatRequest struct {
+	Model     string        `json:"model"`
+	Messages  []ChatMessage `json:"messages"`
+	MaxTokens int           `json:"max_tokens,omitempty"`
+	// Temperature and TopP are left out when nil, so that zero can be sent
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+}
+
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ChatResponse struct {
+	ID      string       `json:"id"`
+	Choices []ChatChoice `json:"choices"`
+}
+
+type ChatChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type EmbeddingResponse struct {
+	Data []EmbeddingData `json:"data"`
+}
+
+type EmbeddingData struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
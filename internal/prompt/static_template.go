@@ -26,6 +26,20 @@ func (p *StaticTemplate) GetPromptTemplateForQuestion(question string) string {
 	return p.getStandardTemplate()
 }
 
+// GetTemplateNameForQuestion names the template returned by GetPromptTemplateForQuestion,
+// so that answers can record which template produced them
+func (p *StaticTemplate) GetTemplateNameForQuestion(question string) string {
+	if p.detectComplexity(question) == "SIMPLE" {
+		return "static_simple"
+	}
+	return "static_standard"
+}
+
+// GetComplexityForQuestion returns SIMPLE or COMPLEX, the classification used to choose the template
+func (p *StaticTemplate) GetComplexityForQuestion(question string) string {
+	return p.detectComplexity(question)
+}
+
 func (p *StaticTemplate) detectComplexity(question string) string {
 	question = strings.ToLower(question)
 
@@ -0,0 +1,99 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/answer"
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/config"
+	"github.com/PhilNel/go-boardgame-assistant/internal/embedding"
+	"github.com/PhilNel/go-boardgame-assistant/internal/glossary"
+	"github.com/PhilNel/go-boardgame-assistant/internal/knowledge"
+	"github.com/PhilNel/go-boardgame-assistant/internal/openai"
+	"github.com/PhilNel/go-boardgame-assistant/internal/prompt"
+	"github.com/PhilNel/go-boardgame-assistant/internal/rewrite"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+	"github.com/PhilNel/go-boardgame-assistant/internal/vectorindex"
+)
+
+type AnswerProvider interface {
+	GenerateAnswer(ctx context.Context, request *types.AnswerRequest) (*types.AnswerResponse, error)
+}
+
+// Providers retrieve the knowledge for a question and answer it. The question handler
+// and the eval harness both use them, so an evaluation runs the deployed pipeline.
+type Providers struct {
+	DynamoDBClient aws.DynamoDBClient
+	BedrockClient  aws.BedrockClient
+	GlossaryRepo   glossary.Repository
+	Knowledge      *knowledge.VectorProvider
+	Answer         AnswerProvider
+}
+
+func New(cfg *config.Config) (*Providers, error) {
+	dynamoClient, err := aws.NewDynamoDBClient(cfg.DynamoDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DynamoDB client: %w", err)
+	}
+	knowledgeRepo := knowledge.NewDynamoDBRepository(dynamoClient, cfg.DynamoDB.KnowledgeTable, cfg.RAG.EmbeddingFormat)
+
+	bedrockClient, err := aws.NewAWSBedrockClient(cfg.Bedrock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Bedrock client: %w", err)
+	}
+
+	s3Client, err := aws.NewS3Client(cfg.S3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	glossaryRepo := glossary.NewS3Repository(s3Client, time.Duration(cfg.RAG.CacheTTLHours)*time.Hour)
+	indexRepo := vectorindex.NewS3Repository(s3Client, time.Duration(cfg.RAG.CacheTTLHours)*time.Hour)
+
+	answerProvider, embeddingProvider, err := createModelProviders(cfg, bedrockClient, prompt.NewStaticTemplate())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model providers: %w", err)
+	}
+	queryRewriter, err := createQueryRewriter(cfg, bedrockClient, glossaryRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query rewriter: %w", err)
+	}
+
+	return &Providers{
+		DynamoDBClient: dynamoClient,
+		BedrockClient:  bedrockClient,
+		GlossaryRepo:   glossaryRepo,
+		Knowledge:      knowledge.NewVectorProvider(knowledgeRepo, embeddingProvider, queryRewriter, glossaryRepo, indexRepo, cfg.RAG),
+		Answer:         answerProvider,
+	}, nil
+}
+
+func createModelProviders(cfg *config.Config, bedrockClient aws.BedrockClient, templateProvider answer.TemplateProvider) (AnswerProvider, knowledge.EmbeddingProvider, error) {
+	switch cfg.System.ModelProvider {
+	case "", "bedrock":
+		embeddingProvider, err := embedding.NewBedrockCreator(bedrockClient, cfg.Bedrock)
+		if err != nil {
+			return nil, nil, err
+		}
+		return answer.NewBedrockProvider(bedrockClient, templateProvider, cfg.Bedrock), embeddingProvider, nil
+	case "openai":
+		openaiClient := openai.NewHTTPClient(cfg.OpenAI)
+		return answer.NewOpenAIProvider(openaiClient, templateProvider, cfg.OpenAI), embedding.NewOpenAICreator(openaiClient, cfg.OpenAI), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown model provider: %s", cfg.System.ModelProvider)
+	}
+}
+
+func createQueryRewriter(cfg *config.Config, bedrockClient aws.BedrockClient, glossaryRepo glossary.Repository) (knowledge.QueryRewriter, error) {
+	switch cfg.RAG.QueryRewriter {
+	case "", "none":
+		return nil, nil
+	case "bedrock":
+		return rewrite.NewBedrockRewriter(bedrockClient, cfg.RAG.MaxRewrites), nil
+	case "synonyms":
+		return rewrite.NewSynonymRewriter(glossaryRepo, cfg.RAG.MaxRewrites), nil
+	default:
+		return nil, fmt.Errorf("unknown query rewriter: %s", cfg.RAG.QueryRewriter)
+	}
+}
@@ -0,0 +1,123 @@
+package references
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+)
+
+type cachedGame struct {
+	references map[string]*Reference
+	loadedAt   time.Time
+}
+
+// CachedRepository keeps the references looked up for each game in memory, so that warm
+// Lambda invocations only go to the repository for references they have not seen. A game's
+// cache is dropped once it is older than the TTL. Missing references are not cached, so a
+// reference added by another instance is found on the next lookup rather than after the TTL.
+type CachedRepository struct {
+	repo     ReferenceRepository
+	cacheTTL time.Duration
+	mu       sync.Mutex
+	cache    map[string]*cachedGame
+}
+
+func NewCachedRepository(repo ReferenceRepository, cacheTTL time.Duration) *CachedRepository {
+	return &CachedRepository{
+		repo:     repo,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]*cachedGame),
+	}
+}
+
+func (r *CachedRepository) GetReference(ctx context.Context, gameID, referenceID string) (*Reference, error) {
+	references, err := r.BatchGetReferences(ctx, gameID, []string{referenceID})
+	if err != nil {
+		return nil, err
+	}
+
+	reference, exists := references[referenceID]
+	if !exists {
+		return nil, fmt.Errorf("failed to get reference %s for game %s: %w", referenceID, gameID, aws.ErrItemNotFound)
+	}
+	return reference, nil
+}
+
+func (r *CachedRepository) BatchGetReferences(ctx context.Context, gameID string, referenceIDs []string) (map[string]*Reference, error) {
+	references := make(map[string]*Reference, len(referenceIDs))
+	var missing []string
+
+	r.mu.Lock()
+	game := r.gameCache(gameID)
+	for _, referenceID := range referenceIDs {
+		reference, cached := game.references[referenceID]
+		if !cached {
+			missing = append(missing, referenceID)
+		} else {
+			references[referenceID] = reference
+		}
+	}
+	r.mu.Unlock()
+
+	if len(missing) == 0 {
+		return references, nil
+	}
+
+	found, err := r.repo.BatchGetReferences(ctx, gameID, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	for referenceID, reference := range found {
+		game.references[referenceID] = reference
+		references[referenceID] = reference
+	}
+	r.mu.Unlock()
+
+	return references, nil
+}
+
+// gameCache returns the cache for the game, replacing it when it has expired. r.mu must be held.
+func (r *CachedRepository) gameCache(gameID string) *cachedGame {
+	game, exists := r.cache[gameID]
+	if !exists || time.Since(game.loadedAt) >= r.cacheTTL {
+		game = &cachedGame{references: make(map[string]*Reference), loadedAt: time.Now()}
+		r.cache[gameID] = game
+	}
+	return game
+}
+
+func (r *CachedRepository) SaveReference(ctx context.Context, reference *Reference) error {
+	defer r.invalidate(reference.GameID)
+	return r.repo.SaveReference(ctx, reference)
+}
+
+func (r *CachedRepository) BatchSaveReferences(ctx context.Context, references []*Reference) error {
+	defer func() {
+		for _, reference := range references {
+			r.invalidate(reference.GameID)
+		}
+	}()
+	return r.repo.BatchSaveReferences(ctx, references)
+}
+
+func (r *CachedRepository) DeleteReference(ctx context.Context, gameID, referenceID string) error {
+	defer r.invalidate(gameID)
+	return r.repo.DeleteReference(ctx, gameID, referenceID)
+}
+
+func (r *CachedRepository) ListReferences(ctx context.Context, gameID string, limit int, cursor string) (*ReferencePage, error) {
+	return r.repo.ListReferences(ctx, gameID, limit, cursor)
+}
+
+// invalidate drops the game's cache after a change made through this repository. Changes made
+// by other Lambda instances are only seen once the cache expires.
+func (r *CachedRepository) invalidate(gameID string) {
+	r.mu.Lock()
+	delete(r.cache, gameID)
+	r.mu.Unlock()
+}
@@ -0,0 +1,73 @@
+package references
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCachedRepository(t *testing.T) {
+	testCases := []struct {
+		description        string
+		cacheTTL           time.Duration
+		secondLookup       []string
+		expectedBatchCalls int
+		expectedFound      int
+	}{
+		{
+			description:        "Cached references are not looked up again",
+			cacheTTL:           time.Hour,
+			secondLookup:       []string{"R1-NOISE"},
+			expectedBatchCalls: 1,
+			expectedFound:      1,
+		},
+		{
+			description:        "Missing references are looked up again",
+			cacheTTL:           time.Hour,
+			secondLookup:       []string{"R9-FLEE"},
+			expectedBatchCalls: 2,
+			expectedFound:      0,
+		},
+		{
+			description:        "Only uncached references are looked up",
+			cacheTTL:           time.Hour,
+			secondLookup:       []string{"R1-NOISE", "R2-FIRE"},
+			expectedBatchCalls: 2,
+			expectedFound:      2,
+		},
+		{
+			description:        "Expired games are looked up again",
+			cacheTTL:           0,
+			secondLookup:       []string{"R1-NOISE"},
+			expectedBatchCalls: 2,
+			expectedFound:      1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			repo := &fakeReferenceRepository{
+				references: map[string]*Reference{
+					"R1-NOISE": {ReferenceID: "R1-NOISE", Title: "Noise"},
+					"R2-FIRE":  {ReferenceID: "R2-FIRE", Title: "Fire"},
+				},
+			}
+			cachedRepo := NewCachedRepository(repo, tc.cacheTTL)
+
+			if _, err := cachedRepo.BatchGetReferences(context.Background(), "nemesis", []string{"R1-NOISE", "R9-FLEE"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			found, err := cachedRepo.BatchGetReferences(context.Background(), "nemesis", tc.secondLookup)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if repo.batchCalls != tc.expectedBatchCalls {
+				t.Errorf("Expected %d repository lookups, Got %d", tc.expectedBatchCalls, repo.batchCalls)
+			}
+			if len(found) != tc.expectedFound {
+				t.Errorf("Expected %d references, Got %d", tc.expectedFound, len(found))
+			}
+		})
+	}
+}
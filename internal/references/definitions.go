@@ -0,0 +1,109 @@
+package references
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// ExtractDefinitions reads the references defined in a rule file's YAML front matter and
+// returns them with the rest of the file. Only the references list is read, any other front
+// matter is dropped with it:
+//
+//	---
+//	references:
+//	  - referenceId: R1-SLIME
+//	    title: Slime
+//	    pageReference: p.17
+//	---
+//
+// Content without front matter is returned unchanged.
+func ExtractDefinitions(gameID, content string) ([]*Reference, string, error) {
+	frontMatter, body, found := splitFrontMatter(content)
+	if !found {
+		return nil, content, nil
+	}
+
+	var parsed struct {
+		References []*Reference `yaml:"references"`
+	}
+	if err := yaml.Unmarshal([]byte(frontMatter), &parsed); err != nil {
+		return nil, "", fmt.Errorf("failed to parse front matter: %w", err)
+	}
+
+	for _, reference := range parsed.References {
+		reference.GameID = gameID
+		if err := validateReference(reference); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return parsed.References, body, nil
+}
+
+// splitFrontMatter returns the text between the leading --- lines and the content after them
+func splitFrontMatter(content string) (string, string, bool) {
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, frontMatterDelimiter+"\n") {
+		return "", "", false
+	}
+
+	rest := normalized[len(frontMatterDelimiter)+1:]
+	lines := strings.SplitAfter(rest, "\n")
+
+	offset := 0
+	for _, line := range lines {
+		if strings.TrimRight(line, "\n") == frontMatterDelimiter {
+			return rest[:offset], strings.TrimLeft(rest[offset+len(line):], "\n"), true
+		}
+		offset += len(line)
+	}
+
+	return "", "", false
+}
+
+// ReferenceLookup finds the references that are already saved
+type ReferenceLookup interface {
+	BatchGetReferences(ctx context.Context, gameID string, referenceIDs []string) (map[string]*Reference, error)
+}
+
+// FindUndefinedReferences returns the reference IDs cited in the texts that are neither in
+// defined nor saved, sorted
+func FindUndefinedReferences(ctx context.Context, lookup ReferenceLookup, gameID string, texts []string, defined []*Reference) ([]string, error) {
+	definedIDs := make(map[string]bool, len(defined))
+	for _, reference := range defined {
+		definedIDs[reference.ReferenceID] = true
+	}
+
+	var cited []string
+	for _, text := range texts {
+		for _, citation := range ExtractCitations(text) {
+			if !definedIDs[citation.ReferenceID] && !slices.Contains(cited, citation.ReferenceID) {
+				cited = append(cited, citation.ReferenceID)
+			}
+		}
+	}
+	if len(cited) == 0 {
+		return []string{}, nil
+	}
+
+	saved, err := lookup.BatchGetReferences(ctx, gameID, cited)
+	if err != nil {
+		return nil, err
+	}
+
+	undefined := []string{}
+	for _, referenceID := range cited {
+		if _, exists := saved[referenceID]; !exists {
+			undefined = append(undefined, referenceID)
+		}
+	}
+	slices.Sort(undefined)
+
+	return undefined, nil
+}
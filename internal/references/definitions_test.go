@@ -0,0 +1,77 @@
+package references
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExtractDefinitions(t *testing.T) {
+	testCases := []struct {
+		description  string
+		content      string
+		expectedIDs  []string
+		expectedBody string
+		expectedErr  bool
+	}{
+		{
+			description:  "Front matter with references",
+			content:      "---\nreferences:\n  - referenceId: R1-SLIME\n    title: Slime\n    pageReference: p.17\n---\n\n# Slime\nSlimed characters [[R1-SLIME,17]].",
+			expectedIDs:  []string{"R1-SLIME"},
+			expectedBody: "# Slime\nSlimed characters [[R1-SLIME,17]].",
+		},
+		{
+			description:  "Front matter without references is dropped",
+			content:      "---\r\nauthor: rules team\r\n---\r\n# Fire",
+			expectedBody: "# Fire",
+		},
+		{
+			description:  "No front matter",
+			content:      "# Fire\n---\nFire spreads.",
+			expectedBody: "# Fire\n---\nFire spreads.",
+		},
+		{
+			description:  "Unclosed front matter is content",
+			content:      "---\nreferences: []\n# Fire",
+			expectedBody: "---\nreferences: []\n# Fire",
+		},
+		{
+			description: "Reference without a title",
+			content:     "---\nreferences:\n  - referenceId: R1-SLIME\n---\n# Slime",
+			expectedErr: true,
+		},
+		{
+			description: "Invalid YAML",
+			content:     "---\nreferences: [\n---\n# Slime",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			definitions, body, err := ExtractDefinitions("nemesis", tc.content)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected an error, Got %d definitions", len(definitions))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var ids []string
+			for _, reference := range definitions {
+				ids = append(ids, reference.ReferenceID)
+				if reference.GameID != "nemesis" {
+					t.Errorf("Expected game ID nemesis, Got %s", reference.GameID)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.expectedIDs) {
+				t.Errorf("Expected definitions %v, Got %v", tc.expectedIDs, ids)
+			}
+			if body != tc.expectedBody {
+				t.Errorf("Expected body %q, Got %q", tc.expectedBody, body)
+			}
+		})
+	}
+}
@@ -23,14 +23,16 @@ func NewDynamoDBRepository(dynamoClient aws.DynamoDBClient, referencesTable stri
 	}
 }
 
-func (r *DynamoDBRepository) GetReference(ctx context.Context, gameID, referenceID string) (*Reference, error) {
-	key := map[string]dynamoTypes.AttributeValue{
+func referenceKey(gameID, referenceID string) map[string]dynamoTypes.AttributeValue {
+	return map[string]dynamoTypes.AttributeValue{
 		"gameId":      &dynamoTypes.AttributeValueMemberS{Value: gameID},
 		"referenceId": &dynamoTypes.AttributeValueMemberS{Value: referenceID},
 	}
+}
 
+func (r *DynamoDBRepository) GetReference(ctx context.Context, gameID, referenceID string) (*Reference, error) {
 	var reference Reference
-	err := r.dynamoDB.GetItem(ctx, r.referencesTable, key, &reference)
+	err := r.dynamoDB.GetItem(ctx, r.referencesTable, referenceKey(gameID, referenceID), &reference)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get reference %s for game %s: %w", referenceID, gameID, err)
 	}
@@ -38,3 +40,80 @@ func (r *DynamoDBRepository) GetReference(ctx context.Context, gameID, reference
 	log.Printf("Successfully retrieved reference: %s-%s", gameID, referenceID)
 	return &reference, nil
 }
+
+// BatchGetReferences returns the references that exist for the IDs, keyed by reference ID
+func (r *DynamoDBRepository) BatchGetReferences(ctx context.Context, gameID string, referenceIDs []string) (map[string]*Reference, error) {
+	keys := make([]map[string]dynamoTypes.AttributeValue, 0, len(referenceIDs))
+	for _, referenceID := range referenceIDs {
+		keys = append(keys, referenceKey(gameID, referenceID))
+	}
+
+	var found []*Reference
+	if err := r.dynamoDB.BatchGetItems(ctx, r.referencesTable, keys, &found); err != nil {
+		return nil, fmt.Errorf("failed to get %d references for game %s: %w", len(referenceIDs), gameID, err)
+	}
+
+	references := make(map[string]*Reference, len(found))
+	for _, reference := range found {
+		references[reference.ReferenceID] = reference
+	}
+
+	log.Printf("Retrieved %d of %d references for game: %s", len(references), len(referenceIDs), gameID)
+	return references, nil
+}
+
+func (r *DynamoDBRepository) SaveReference(ctx context.Context, reference *Reference) error {
+	if err := r.dynamoDB.PutItem(ctx, r.referencesTable, reference); err != nil {
+		return fmt.Errorf("failed to save reference %s for game %s: %w", reference.ReferenceID, reference.GameID, err)
+	}
+
+	log.Printf("Saved reference: %s-%s", reference.GameID, reference.ReferenceID)
+	return nil
+}
+
+func (r *DynamoDBRepository) BatchSaveReferences(ctx context.Context, references []*Reference) error {
+	items := make([]interface{}, len(references))
+	for i, reference := range references {
+		items[i] = reference
+	}
+
+	if err := r.dynamoDB.BatchWriteItems(ctx, r.referencesTable, items); err != nil {
+		return fmt.Errorf("failed to save %d references: %w", len(references), err)
+	}
+
+	log.Printf("Saved %d references", len(references))
+	return nil
+}
+
+func (r *DynamoDBRepository) DeleteReference(ctx context.Context, gameID, referenceID string) error {
+	if err := r.dynamoDB.DeleteItem(ctx, r.referencesTable, referenceKey(gameID, referenceID)); err != nil {
+		return fmt.Errorf("failed to delete reference %s for game %s: %w", referenceID, gameID, err)
+	}
+
+	log.Printf("Deleted reference: %s-%s", gameID, referenceID)
+	return nil
+}
+
+// ListReferences returns a page of a game's references in reference ID order
+func (r *DynamoDBRepository) ListReferences(ctx context.Context, gameID string, limit int, cursor string) (*ReferencePage, error) {
+	input := &aws.PageInput{
+		TableName:    r.referencesTable,
+		KeyCondition: "gameId = :gameId",
+		ExpressionValues: map[string]dynamoTypes.AttributeValue{
+			":gameId": &dynamoTypes.AttributeValueMemberS{Value: gameID},
+		},
+		Limit:  int32(limit),
+		Cursor: cursor,
+	}
+
+	var references []*Reference
+	nextCursor, err := r.dynamoDB.QueryPage(ctx, input, &references)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references for game %s: %w", gameID, err)
+	}
+
+	return &ReferencePage{
+		Items:      references,
+		NextCursor: nextCursor,
+	}, nil
+}
@@ -0,0 +1,234 @@
+package references
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ImportReferences saves the references defined in the game's references files, replacing
+// existing references with the same ID. References files are YAML, CSV or JSON files under
+// games/<game>/references, such as games/<game>/references.yaml or games/<game>/references/core.csv.
+func (m *Manager) ImportReferences(ctx context.Context, gameID string) (*ImportResult, error) {
+	if gameID == "" {
+		return nil, &ValidationError{
+			Code:    "INVALID_GAME_ID",
+			Message: "Game ID is required",
+		}
+	}
+
+	files, err := m.fileProvider.GetFiles(ctx, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files for game %s: %w", gameID, err)
+	}
+
+	referenceFiles, ruleFiles := splitGameFiles(gameID, files)
+	if len(referenceFiles) == 0 {
+		return nil, &ValidationError{
+			Code:    "NO_REFERENCES_FILES",
+			Message: fmt.Sprintf("No references files found under games/%s/references", strings.ToLower(gameID)),
+		}
+	}
+
+	references, err := m.readReferenceFiles(ctx, gameID, referenceFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.referenceRepo.BatchSaveReferences(ctx, references); err != nil {
+		return nil, err
+	}
+
+	undefined, err := m.findUndefinedReferences(ctx, gameID, ruleFiles, references)
+	if err != nil {
+		return nil, err
+	}
+	if len(undefined) > 0 {
+		log.Printf("WARNING: %d references cited in the rules of %s are not defined: %v", len(undefined), gameID, undefined)
+	}
+
+	log.Printf("Imported %d references from %d files for game: %s", len(references), len(referenceFiles), gameID)
+	return &ImportResult{
+		GameID:    gameID,
+		Files:     referenceFiles,
+		Imported:  len(references),
+		Undefined: undefined,
+	}, nil
+}
+
+// splitGameFiles returns the references files and the rule files that cite them
+func splitGameFiles(gameID string, files []string) ([]string, []string) {
+	referencesPrefix := fmt.Sprintf("games/%s/references", strings.ToLower(gameID))
+
+	var referenceFiles, ruleFiles []string
+	for _, file := range files {
+		switch {
+		case strings.HasPrefix(file, referencesPrefix) && isReferencesFormat(file):
+			referenceFiles = append(referenceFiles, file)
+		case strings.HasSuffix(file, ".md") || strings.HasSuffix(file, ".txt"):
+			ruleFiles = append(ruleFiles, file)
+		}
+	}
+	return referenceFiles, ruleFiles
+}
+
+func isReferencesFormat(file string) bool {
+	switch path.Ext(file) {
+	case ".yaml", ".yml", ".csv", ".json":
+		return true
+	}
+	return false
+}
+
+func (m *Manager) readReferenceFiles(ctx context.Context, gameID string, files []string) ([]*Reference, error) {
+	var references []*Reference
+	definedIn := make(map[string]string)
+
+	for _, file := range files {
+		content, err := m.fileProvider.GetFileContent(ctx, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get references file %s: %w", file, err)
+		}
+
+		parsed, err := ParseReferences(file, content)
+		if err != nil {
+			return nil, &ValidationError{
+				Code:    "INVALID_REFERENCES_FILE",
+				Message: fmt.Sprintf("Failed to parse %s: %v", file, err),
+			}
+		}
+
+		for _, reference := range parsed {
+			reference.GameID = gameID
+			if err := validateReference(reference); err != nil {
+				return nil, &ValidationError{
+					Code:    "INVALID_REFERENCES_FILE",
+					Message: fmt.Sprintf("Invalid reference in %s: %v", file, err),
+				}
+			}
+			if previousFile, exists := definedIn[reference.ReferenceID]; exists {
+				return nil, &ValidationError{
+					Code:    "DUPLICATE_REFERENCE",
+					Message: fmt.Sprintf("Reference %s is defined in both %s and %s", reference.ReferenceID, previousFile, file),
+				}
+			}
+
+			definedIn[reference.ReferenceID] = file
+			references = append(references, reference)
+		}
+	}
+
+	return references, nil
+}
+
+// ParseReferences reads a YAML, CSV or JSON references file, chosen by the file extension.
+// YAML and JSON files hold a list of references, CSV files have a header row naming the
+// reference fields (referenceId, type, title, section, pageReference and url).
+func ParseReferences(file string, content []byte) ([]*Reference, error) {
+	var references []*Reference
+	var err error
+
+	switch path.Ext(file) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &references)
+	case ".json":
+		err = json.Unmarshal(content, &references)
+	case ".csv":
+		references, err = parseReferencesCSV(content)
+	default:
+		err = fmt.Errorf("unsupported references file format: %s", path.Ext(file))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return references, nil
+}
+
+func parseReferencesCSV(content []byte) ([]*Reference, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	fields := make([]func(*Reference) *string, len(header))
+	for i, column := range header {
+		fields[i] = csvField(strings.TrimSpace(column))
+		if fields[i] == nil {
+			return nil, fmt.Errorf("unknown column: %s", column)
+		}
+	}
+
+	var references []*Reference
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		reference := &Reference{}
+		for i, value := range record {
+			*fields[i](reference) = strings.TrimSpace(value)
+		}
+		references = append(references, reference)
+	}
+
+	return references, nil
+}
+
+func csvField(column string) func(*Reference) *string {
+	switch strings.ToLower(column) {
+	case "referenceid":
+		return func(r *Reference) *string { return &r.ReferenceID }
+	case "type":
+		return func(r *Reference) *string { return &r.Type }
+	case "title":
+		return func(r *Reference) *string { return &r.Title }
+	case "section":
+		return func(r *Reference) *string { return &r.Section }
+	case "pagereference":
+		return func(r *Reference) *string { return &r.PageReference }
+	case "url":
+		return func(r *Reference) *string { return &r.URL }
+	}
+	return nil
+}
+
+// findUndefinedReferences returns the reference IDs cited in the rule files that are not defined
+// in the imported references, the rule files' front matter or the references table
+func (m *Manager) findUndefinedReferences(ctx context.Context, gameID string, ruleFiles []string, imported []*Reference) ([]string, error) {
+	defined := slices.Clone(imported)
+	var rules []string
+	for _, file := range ruleFiles {
+		content, err := m.fileProvider.GetFileContent(ctx, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rule file %s: %w", file, err)
+		}
+
+		definitions, body, err := ExtractDefinitions(gameID, string(content))
+		if err != nil {
+			log.Printf("WARNING: Ignoring the reference definitions in %s: %v", file, err)
+			body = string(content)
+		}
+		defined = append(defined, definitions...)
+		rules = append(rules, body)
+	}
+
+	return FindUndefinedReferences(ctx, m.referenceRepo, gameID, rules, defined)
+}
@@ -0,0 +1,251 @@
+package references
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeFileProvider struct {
+	files map[string]string
+}
+
+func (f *fakeFileProvider) GetFiles(ctx context.Context, gameName string) ([]string, error) {
+	var files []string
+	for file := range f.files {
+		if strings.HasPrefix(file, "games/"+gameName+"/") {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func (f *fakeFileProvider) GetFileContent(ctx context.Context, filePath string) ([]byte, error) {
+	return []byte(f.files[filePath]), nil
+}
+
+func TestParseReferences(t *testing.T) {
+	testCases := []struct {
+		description   string
+		file          string
+		content       string
+		expectedIDs   []string
+		expectedTitle string
+		expectedErr   bool
+	}{
+		{
+			description:   "YAML list",
+			file:          "games/nemesis/references.yaml",
+			content:       "- referenceId: R1-NOISE\n  title: Noise rolls\n  pageReference: p.12\n- referenceId: R2-FIRE\n  title: Fire\n",
+			expectedIDs:   []string{"R1-NOISE", "R2-FIRE"},
+			expectedTitle: "Noise rolls",
+		},
+		{
+			description:   "JSON list",
+			file:          "games/nemesis/references/core.json",
+			content:       `[{"referenceId": "R1-NOISE", "title": "Noise rolls"}]`,
+			expectedIDs:   []string{"R1-NOISE"},
+			expectedTitle: "Noise rolls",
+		},
+		{
+			description:   "CSV with a header row",
+			file:          "games/nemesis/references.csv",
+			content:       "referenceId, title, section\nR1-NOISE, \"Noise rolls, basic\", Noise\nR2-FIRE, Fire, Fire\n",
+			expectedIDs:   []string{"R1-NOISE", "R2-FIRE"},
+			expectedTitle: "Noise rolls, basic",
+		},
+		{
+			description: "CSV with an unknown column",
+			file:        "games/nemesis/references.csv",
+			content:     "referenceId,chapter\nR1-NOISE,1\n",
+			expectedErr: true,
+		},
+		{
+			description: "Unsupported format",
+			file:        "games/nemesis/references.xml",
+			content:     "<references/>",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			references, err := ParseReferences(tc.file, []byte(tc.content))
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected an error, Got %d references", len(references))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var ids []string
+			for _, reference := range references {
+				ids = append(ids, reference.ReferenceID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.expectedIDs) {
+				t.Errorf("Expected references %v, Got %v", tc.expectedIDs, ids)
+			}
+			if references[0].Title != tc.expectedTitle {
+				t.Errorf("Expected title %q, Got %q", tc.expectedTitle, references[0].Title)
+			}
+		})
+	}
+}
+
+func TestImportReferences(t *testing.T) {
+	rules := "Roll for noise [[R1-NOISE,12]]. Fire spreads [[R2-FIRE]]. Intruders flee [[R9-FLEE]] and hide [[R3-HIDE]]."
+
+	testCases := []struct {
+		description       string
+		files             map[string]string
+		expectedImported  int
+		expectedUndefined []string
+		expectedCode      string
+	}{
+		{
+			description: "Reports cited references that are not defined or saved",
+			files: map[string]string{
+				"games/nemesis/references.yaml": "- referenceId: R1-NOISE\n  title: Noise\n- referenceId: R2-FIRE\n  title: Fire\n",
+				"games/nemesis/rules.md":        rules,
+			},
+			expectedImported:  2,
+			expectedUndefined: []string{"R9-FLEE"},
+		},
+		{
+			description: "Reads every references file",
+			files: map[string]string{
+				"games/nemesis/references/core.csv":  "referenceId,title\nR1-NOISE,Noise\nR2-FIRE,Fire\n",
+				"games/nemesis/references/more.json": `[{"referenceId": "R9-FLEE", "title": "Fleeing"}]`,
+				"games/nemesis/rules.md":             rules,
+			},
+			expectedImported:  3,
+			expectedUndefined: []string{},
+		},
+		{
+			description: "No references files",
+			files: map[string]string{
+				"games/nemesis/rules.md": rules,
+			},
+			expectedCode: "NO_REFERENCES_FILES",
+		},
+		{
+			description: "Reference defined twice",
+			files: map[string]string{
+				"games/nemesis/references/core.csv":  "referenceId,title\nR1-NOISE,Noise\n",
+				"games/nemesis/references/more.json": `[{"referenceId": "R1-NOISE", "title": "Noise again"}]`,
+			},
+			expectedCode: "DUPLICATE_REFERENCE",
+		},
+		{
+			description: "Reference without a title",
+			files: map[string]string{
+				"games/nemesis/references.json": `[{"referenceId": "R1-NOISE"}]`,
+			},
+			expectedCode: "INVALID_REFERENCES_FILE",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			repo := &fakeReferenceRepository{
+				references: map[string]*Reference{
+					"R3-HIDE": {GameID: "nemesis", ReferenceID: "R3-HIDE", Title: "Hiding"},
+				},
+			}
+			manager := NewManager(repo, &fakeFileProvider{files: tc.files})
+
+			result, err := manager.ImportReferences(context.Background(), "nemesis")
+			if tc.expectedCode != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Code != tc.expectedCode {
+					t.Errorf("Expected a %s error, Got %v", tc.expectedCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result.Imported != tc.expectedImported {
+				t.Errorf("Expected %d references imported, Got %d", tc.expectedImported, result.Imported)
+			}
+			if fmt.Sprint(result.Undefined) != fmt.Sprint(tc.expectedUndefined) {
+				t.Errorf("Expected undefined references %v, Got %v", tc.expectedUndefined, result.Undefined)
+			}
+			if reference := repo.references["R1-NOISE"]; reference == nil || reference.GameID != "nemesis" {
+				t.Errorf("Expected R1-NOISE to be saved for nemesis, Got %+v", reference)
+			}
+		})
+	}
+}
+
+func TestManagerSaveReference(t *testing.T) {
+	testCases := []struct {
+		description  string
+		reference    *Reference
+		update       bool
+		expectedCode string
+	}{
+		{
+			description: "Create a new reference",
+			reference:   &Reference{GameID: "nemesis", ReferenceID: "R2-FIRE", Title: "Fire"},
+		},
+		{
+			description:  "Create an existing reference",
+			reference:    &Reference{GameID: "nemesis", ReferenceID: "R1-NOISE", Title: "Noise"},
+			expectedCode: "REFERENCE_EXISTS",
+		},
+		{
+			description: "Update an existing reference",
+			reference:   &Reference{GameID: "nemesis", ReferenceID: "R1-NOISE", Title: "Noise rolls"},
+			update:      true,
+		},
+		{
+			description:  "Update a missing reference",
+			reference:    &Reference{GameID: "nemesis", ReferenceID: "R2-FIRE", Title: "Fire"},
+			update:       true,
+			expectedCode: "REFERENCE_NOT_FOUND",
+		},
+		{
+			description:  "Reference ID that cannot be cited",
+			reference:    &Reference{GameID: "nemesis", ReferenceID: "r2 fire", Title: "Fire"},
+			expectedCode: "INVALID_REFERENCE_ID",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			repo := &fakeReferenceRepository{
+				references: map[string]*Reference{
+					"R1-NOISE": {GameID: "nemesis", ReferenceID: "R1-NOISE", Title: "Noise"},
+				},
+			}
+			manager := NewManager(repo, nil)
+
+			save := manager.CreateReference
+			if tc.update {
+				save = manager.UpdateReference
+			}
+			_, err := save(context.Background(), tc.reference)
+
+			if tc.expectedCode != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Code != tc.expectedCode {
+					t.Errorf("Expected a %s error, Got %v", tc.expectedCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if repo.references[tc.reference.ReferenceID] != tc.reference {
+				t.Errorf("Expected reference %s to be saved", tc.reference.ReferenceID)
+			}
+		})
+	}
+}
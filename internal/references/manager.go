@@ -0,0 +1,155 @@
+package references
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+)
+
+// Reference IDs must be citable with the [[REFERENCE-ID]] syntax
+var referenceIDPattern = regexp.MustCompile(`^[A-Z0-9\-_]+$`)
+
+// Manager maintains the references of each game
+type Manager struct {
+	referenceRepo ReferenceRepository
+	fileProvider  FileProvider
+}
+
+// fileProvider reads the references and rule files for imports
+func NewManager(referenceRepo ReferenceRepository, fileProvider FileProvider) *Manager {
+	return &Manager{
+		referenceRepo: referenceRepo,
+		fileProvider:  fileProvider,
+	}
+}
+
+func (m *Manager) CreateReference(ctx context.Context, reference *Reference) (*Reference, error) {
+	if err := validateReference(reference); err != nil {
+		return nil, err
+	}
+
+	exists, err := m.referenceExists(ctx, reference.GameID, reference.ReferenceID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, &ValidationError{
+			Code:    "REFERENCE_EXISTS",
+			Message: fmt.Sprintf("Reference %s already exists for game %s", reference.ReferenceID, reference.GameID),
+		}
+	}
+
+	if err := m.referenceRepo.SaveReference(ctx, reference); err != nil {
+		return nil, err
+	}
+	return reference, nil
+}
+
+func (m *Manager) UpdateReference(ctx context.Context, reference *Reference) (*Reference, error) {
+	if err := validateReference(reference); err != nil {
+		return nil, err
+	}
+
+	exists, err := m.referenceExists(ctx, reference.GameID, reference.ReferenceID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFoundError(reference.GameID, reference.ReferenceID)
+	}
+
+	if err := m.referenceRepo.SaveReference(ctx, reference); err != nil {
+		return nil, err
+	}
+	return reference, nil
+}
+
+func (m *Manager) DeleteReference(ctx context.Context, gameID, referenceID string) error {
+	if gameID == "" || referenceID == "" {
+		return &ValidationError{
+			Code:    "INVALID_REFERENCE",
+			Message: "Game ID and reference ID are required",
+		}
+	}
+
+	err := m.referenceRepo.DeleteReference(ctx, gameID, referenceID)
+	if errors.Is(err, aws.ErrItemNotFound) {
+		return notFoundError(gameID, referenceID)
+	}
+	return err
+}
+
+func (m *Manager) ListReferences(ctx context.Context, gameID string, limit int, cursor string) (*ReferencePage, error) {
+	if gameID == "" {
+		return nil, &ValidationError{
+			Code:    "INVALID_GAME_ID",
+			Message: "Game ID is required",
+		}
+	}
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	page, err := m.referenceRepo.ListReferences(ctx, gameID, limit, cursor)
+	if errors.Is(err, aws.ErrInvalidCursor) {
+		return nil, &ValidationError{
+			Code:    "INVALID_CURSOR",
+			Message: "Cursor is not valid",
+		}
+	}
+	return page, err
+}
+
+func (m *Manager) referenceExists(ctx context.Context, gameID, referenceID string) (bool, error) {
+	_, err := m.referenceRepo.GetReference(ctx, gameID, referenceID)
+	if errors.Is(err, aws.ErrItemNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func validateReference(reference *Reference) error {
+	if strings.TrimSpace(reference.GameID) == "" {
+		return &ValidationError{
+			Code:    "INVALID_GAME_ID",
+			Message: "Game ID is required",
+		}
+	}
+
+	if !referenceIDPattern.MatchString(reference.ReferenceID) {
+		return &ValidationError{
+			Code:    "INVALID_REFERENCE_ID",
+			Message: fmt.Sprintf("Reference ID %q must only contain upper case letters, digits, - and _", reference.ReferenceID),
+		}
+	}
+
+	if strings.TrimSpace(reference.Title) == "" {
+		return &ValidationError{
+			Code:    "INVALID_TITLE",
+			Message: fmt.Sprintf("Title is required for reference %s", reference.ReferenceID),
+		}
+	}
+
+	return nil
+}
+
+func notFoundError(gameID, referenceID string) error {
+	return &ValidationError{
+		Code:    "REFERENCE_NOT_FOUND",
+		Message: fmt.Sprintf("Reference %s not found for game %s", referenceID, gameID),
+	}
+}
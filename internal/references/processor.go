@@ -5,66 +5,78 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"slices"
 	"sort"
+	"strings"
+	"unicode/utf16"
 )
 
-// Unicode superscript digits for footnotes
-var footnoteNumbers = []string{"¹", "²", "³", "⁴", "⁵", "⁶", "⁷", "⁸", "⁹", "¹⁰", "¹¹", "¹²", "¹³", "¹⁴", "¹⁵", "¹⁶", "¹⁷", "¹⁸", "¹⁹", "²⁰"}
+// Matches [[REFERENCE-ID]] or [[REFERENCE-ID,page]]
+var citationPattern = regexp.MustCompile(`\[\[([A-Z0-9\-_]+)(?:,(\d+))?\]\]`)
 
 type ReferenceProcessor struct {
 	referenceRepo ReferenceRepository
+	dropInvalid   bool
 }
 
-func NewReferenceProcessor(referenceRepo ReferenceRepository) *ReferenceProcessor {
+// NewReferenceProcessor creates a processor that removes citations of references that were not
+// in the retrieved knowledge when dropInvalid is set, and marks them as unverified otherwise
+func NewReferenceProcessor(referenceRepo ReferenceRepository, dropInvalid bool) *ReferenceProcessor {
 	return &ReferenceProcessor{
 		referenceRepo: referenceRepo,
+		dropInvalid:   dropInvalid,
 	}
 }
 
-func (p *ReferenceProcessor) Process(ctx context.Context, gameID, responseText string) (*ProcessedResponse, error) {
+func (p *ReferenceProcessor) Process(ctx context.Context, gameID, responseText string, retrievedIDs map[string]bool, renderer FootnoteRenderer) (*ProcessedResponse, error) {
 	log.Printf("Processing references for game: %s, text length: %d", gameID, len(responseText))
 
-	citations, err := p.extractCitations(responseText)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract citations: %w", err)
+	if renderer == nil {
+		renderer = superscriptRenderer{}
 	}
 
+	citations := ExtractCitations(responseText)
 	if len(citations) == 0 {
 		log.Printf("No citations found in response text")
 		return &ProcessedResponse{
-			Response:   responseText,
+			Response:   escapeText(renderer, responseText),
 			References: nil,
 		}, nil
 	}
 
 	log.Printf("Found %d citations to process", len(citations))
 
-	footnoteMap, references, err := p.buildFootnoteMapping(ctx, gameID, citations)
+	invalidIDs := findInvalidCitations(citations, retrievedIDs)
+	if len(invalidIDs) > 0 {
+		log.Printf("WARNING: Found %d cited references that were not in the retrieved knowledge: %v", len(invalidIDs), invalidIDs)
+		if p.dropInvalid {
+			responseText = removeCitations(responseText, citations, invalidIDs)
+			citations = ExtractCitations(responseText)
+		}
+	}
+
+	footnoteMap, references, err := p.buildFootnoteMapping(ctx, gameID, citations, invalidIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build footnote mapping: %w", err)
 	}
 
-	processedText := p.replaceCitationsWithFootnotes(responseText, citations, footnoteMap)
+	processedText, spans := p.replaceCitationsWithFootnotes(responseText, citations, footnoteMap, renderer)
 
 	result := &ProcessedResponse{
-		Response:   processedText,
-		References: references,
+		Response:         processedText,
+		References:       references,
+		InvalidCitations: invalidIDs,
+		CitationSpans:    spans,
 	}
 
 	log.Printf("Citation processing completed. Found %d unique references", len(references))
 	return result, nil
 }
 
-func (p *ReferenceProcessor) extractCitations(text string) ([]*Citation, error) {
-	// Regex pattern to match [[REFERENCE-ID]] or [[REFERENCE-ID,page]]
-	pattern := `\[\[([A-Z0-9\-_]+)(?:,(\d+))?\]\]`
-	regex, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compile citation regex: %w", err)
-	}
-
-	matches := regex.FindAllStringSubmatch(text, -1)
-	matchIndices := regex.FindAllStringIndex(text, -1)
+// ExtractCitations returns the citations in the text in the order they appear
+func ExtractCitations(text string) []*Citation {
+	matches := citationPattern.FindAllStringSubmatch(text, -1)
+	matchIndices := citationPattern.FindAllStringIndex(text, -1)
 
 	var citations []*Citation
 	for i, match := range matches {
@@ -92,15 +104,49 @@ func (p *ReferenceProcessor) extractCitations(text string) ([]*Citation, error)
 		return citations[i].StartPos < citations[j].StartPos
 	})
 
-	return citations, nil
+	return citations
+}
+
+// findInvalidCitations returns the cited reference IDs missing from retrievedIDs, in the order they are first cited
+func findInvalidCitations(citations []*Citation, retrievedIDs map[string]bool) []string {
+	if retrievedIDs == nil {
+		return nil
+	}
+
+	var invalidIDs []string
+	for _, citation := range citations {
+		if !retrievedIDs[citation.ReferenceID] && !slices.Contains(invalidIDs, citation.ReferenceID) {
+			invalidIDs = append(invalidIDs, citation.ReferenceID)
+		}
+	}
+	return invalidIDs
+}
+
+// removeCitations removes the citations of the given reference IDs along with the space before them
+func removeCitations(text string, citations []*Citation, referenceIDs []string) string {
+	result := text
+	for i := len(citations) - 1; i >= 0; i-- {
+		citation := citations[i]
+		if !slices.Contains(referenceIDs, citation.ReferenceID) {
+			continue
+		}
+
+		start := citation.StartPos
+		if start > 0 && result[start-1] == ' ' {
+			start--
+		}
+		result = result[:start] + result[citation.EndPos:]
+	}
+	return result
 }
 
-func (p *ReferenceProcessor) buildFootnoteMapping(ctx context.Context, gameID string, citations []*Citation) (map[string]string, []*ReferenceInfo, error) {
-	footnoteMap := make(map[string]string)
+func (p *ReferenceProcessor) buildFootnoteMapping(ctx context.Context, gameID string, citations []*Citation, invalidIDs []string) (map[string]*ReferenceInfo, []*ReferenceInfo, error) {
+	footnoteMap := make(map[string]*ReferenceInfo)
 	var references []*ReferenceInfo
 	footnoteCounter := 1
 
 	seenReferences := make(map[string]bool)
+	foundReferences := p.lookupReferences(ctx, gameID, citations)
 
 	for _, citation := range citations {
 		citationKey := citation.ReferenceID
@@ -112,22 +158,17 @@ func (p *ReferenceProcessor) buildFootnoteMapping(ctx context.Context, gameID st
 			continue
 		}
 
-		if footnoteCounter > len(footnoteNumbers) {
-			log.Printf("WARNING: Too many unique citations (%d), using number fallback", footnoteCounter)
-			footnoteMap[citationKey] = fmt.Sprintf("^%d", footnoteCounter)
-		} else {
-			footnoteMap[citationKey] = footnoteNumbers[footnoteCounter-1]
-		}
-
-		reference, err := p.referenceRepo.GetReference(ctx, gameID, citation.ReferenceID)
+		reference, exists := foundReferences[citation.ReferenceID]
 		var referenceToAppend *ReferenceInfo
-		if err != nil {
-			log.Printf("WARNING: Failed to lookup reference %s: %v", citation.ReferenceID, err)
+		if !exists {
+			log.Printf("WARNING: Reference %s not found for game %s", citation.ReferenceID, gameID)
 			referenceToAppend = p.createPlaceholderReference(footnoteCounter, citation.Page)
 		} else {
 			referenceToAppend = p.createPageReference(footnoteCounter, reference, citation.Page)
 		}
+		referenceToAppend.Unverified = slices.Contains(invalidIDs, citation.ReferenceID)
 		references = append(references, referenceToAppend)
+		footnoteMap[citationKey] = referenceToAppend
 
 		seenReferences[citationKey] = true
 		footnoteCounter++
@@ -136,6 +177,27 @@ func (p *ReferenceProcessor) buildFootnoteMapping(ctx context.Context, gameID st
 	return footnoteMap, references, nil
 }
 
+// lookupReferences gets the cited references in one batch. A failed lookup is logged and
+// leaves every citation with a placeholder reference rather than failing the answer.
+func (p *ReferenceProcessor) lookupReferences(ctx context.Context, gameID string, citations []*Citation) map[string]*Reference {
+	var referenceIDs []string
+	for _, citation := range citations {
+		if !slices.Contains(referenceIDs, citation.ReferenceID) {
+			referenceIDs = append(referenceIDs, citation.ReferenceID)
+		}
+	}
+	if len(referenceIDs) == 0 {
+		return nil
+	}
+
+	references, err := p.referenceRepo.BatchGetReferences(ctx, gameID, referenceIDs)
+	if err != nil {
+		log.Printf("WARNING: Failed to lookup %d references for game %s: %v", len(referenceIDs), gameID, err)
+		return nil
+	}
+	return references
+}
+
 func (p *ReferenceProcessor) createPlaceholderReference(footnoteCounter int, page string) *ReferenceInfo {
 	return &ReferenceInfo{
 		ID:      footnoteCounter,
@@ -161,25 +223,58 @@ func (p *ReferenceProcessor) createPageReference(footnoteCounter int, reference
 	}
 }
 
-func (p *ReferenceProcessor) replaceCitationsWithFootnotes(text string, citations []*Citation, footnoteMap map[string]string) string {
-	// Work backwards through citations to maintain correct positions
-	result := text
-	for i := len(citations) - 1; i >= 0; i-- {
-		citation := citations[i]
+// replaceCitationsWithFootnotes renders the footnote of each citation in its place and
+// returns where the footnotes ended up in the result. The text between the footnotes is
+// escaped first for renderers that write markup, so the spans point into the escaped text.
+func (p *ReferenceProcessor) replaceCitationsWithFootnotes(text string, citations []*Citation, footnoteMap map[string]*ReferenceInfo, renderer FootnoteRenderer) (string, []*CitationSpan) {
+	var result strings.Builder
+	var spans []*CitationSpan
+	position := 0
+	offset := 0
 
+	for _, citation := range citations {
 		citationKey := citation.ReferenceID
 		if citation.Page != "" {
 			citationKey += "," + citation.Page
 		}
 
-		footnote, exists := footnoteMap[citationKey]
+		reference, exists := footnoteMap[citationKey]
 		if !exists {
 			log.Printf("WARNING: No footnote found for citation %s", citationKey)
 			continue
 		}
 
-		result = result[:citation.StartPos] + footnote + result[citation.EndPos:]
+		footnote := renderer.Render(reference)
+		preceding := text[position:citation.StartPos]
+		if footnote == "" {
+			// Avoid leaving a space before punctuation when the citation is removed
+			preceding = strings.TrimSuffix(preceding, " ")
+		}
+
+		preceding = escapeText(renderer, preceding)
+		result.WriteString(preceding)
+		offset += utf16Length(preceding)
+		result.WriteString(footnote)
+		spans = append(spans, &CitationSpan{ID: reference.ID, Start: offset, End: offset + utf16Length(footnote)})
+		offset += utf16Length(footnote)
+		position = citation.EndPos
 	}
+	result.WriteString(escapeText(renderer, text[position:]))
 
-	return result
+	return result.String(), spans
+}
+
+func escapeText(renderer FootnoteRenderer, text string) string {
+	if escaper, ok := renderer.(TextEscaper); ok {
+		return escaper.Escape(text)
+	}
+	return text
+}
+
+func utf16Length(text string) int {
+	length := 0
+	for _, r := range text {
+		length += utf16.RuneLen(r)
+	}
+	return length
 }
@@ -0,0 +1,129 @@
+package references
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+)
+
+type fakeReferenceRepository struct {
+	references map[string]*Reference
+	batchCalls int
+}
+
+func (r *fakeReferenceRepository) GetReference(ctx context.Context, gameID, referenceID string) (*Reference, error) {
+	reference, exists := r.references[referenceID]
+	if !exists {
+		return nil, fmt.Errorf("reference %s not found: %w", referenceID, aws.ErrItemNotFound)
+	}
+	return reference, nil
+}
+
+func (r *fakeReferenceRepository) SaveReference(ctx context.Context, reference *Reference) error {
+	r.references[reference.ReferenceID] = reference
+	return nil
+}
+
+func (r *fakeReferenceRepository) BatchSaveReferences(ctx context.Context, references []*Reference) error {
+	for _, reference := range references {
+		r.references[reference.ReferenceID] = reference
+	}
+	return nil
+}
+
+func (r *fakeReferenceRepository) DeleteReference(ctx context.Context, gameID, referenceID string) error {
+	if _, exists := r.references[referenceID]; !exists {
+		return aws.ErrItemNotFound
+	}
+	delete(r.references, referenceID)
+	return nil
+}
+
+func (r *fakeReferenceRepository) ListReferences(ctx context.Context, gameID string, limit int, cursor string) (*ReferencePage, error) {
+	page := &ReferencePage{}
+	for _, reference := range r.references {
+		page.Items = append(page.Items, reference)
+	}
+	return page, nil
+}
+
+func (r *fakeReferenceRepository) BatchGetReferences(ctx context.Context, gameID string, referenceIDs []string) (map[string]*Reference, error) {
+	r.batchCalls++
+	references := make(map[string]*Reference)
+	for _, referenceID := range referenceIDs {
+		if reference, exists := r.references[referenceID]; exists {
+			references[referenceID] = reference
+		}
+	}
+	return references, nil
+}
+
+func TestProcessValidatesCitations(t *testing.T) {
+	repo := &fakeReferenceRepository{
+		references: map[string]*Reference{
+			"R1-NOISE": {ReferenceID: "R1-NOISE", Title: "Noise"},
+			"R2-FIRE":  {ReferenceID: "R2-FIRE", Title: "Fire"},
+		},
+	}
+	answer := "Roll a d10 [[R1-NOISE,12]]. Fire spreads [[R2-FIRE]] and intruders flee [[R9-FLEE]]."
+
+	testCases := []struct {
+		description        string
+		dropInvalid        bool
+		retrievedIDs       map[string]bool
+		expectedResponse   string
+		expectedReferences int
+		expectedInvalid    []string
+	}{
+		{
+			description:        "No retrieved IDs skips the check",
+			retrievedIDs:       nil,
+			expectedResponse:   "Roll a d10 ¹. Fire spreads ² and intruders flee ³.",
+			expectedReferences: 3,
+		},
+		{
+			description:        "Drop citations that were not retrieved",
+			dropInvalid:        true,
+			retrievedIDs:       map[string]bool{"R1-NOISE": true},
+			expectedResponse:   "Roll a d10 ¹. Fire spreads and intruders flee.",
+			expectedReferences: 1,
+			expectedInvalid:    []string{"R2-FIRE", "R9-FLEE"},
+		},
+		{
+			description:        "Flag citations that were not retrieved",
+			dropInvalid:        false,
+			retrievedIDs:       map[string]bool{"R1-NOISE": true},
+			expectedResponse:   "Roll a d10 ¹. Fire spreads ² and intruders flee ³.",
+			expectedReferences: 3,
+			expectedInvalid:    []string{"R2-FIRE", "R9-FLEE"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			processor := NewReferenceProcessor(repo, tc.dropInvalid)
+
+			processed, err := processor.Process(context.Background(), "nemesis", answer, tc.retrievedIDs, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if processed.Response != tc.expectedResponse {
+				t.Errorf("Expected response %q, Got %q", tc.expectedResponse, processed.Response)
+			}
+			if len(processed.References) != tc.expectedReferences {
+				t.Fatalf("Expected %d references, Got %d", tc.expectedReferences, len(processed.References))
+			}
+			if fmt.Sprint(processed.InvalidCitations) != fmt.Sprint(tc.expectedInvalid) {
+				t.Errorf("Expected invalid citations %v, Got %v", tc.expectedInvalid, processed.InvalidCitations)
+			}
+			for _, reference := range processed.References[1:] {
+				if reference.Unverified != (tc.expectedInvalid != nil) {
+					t.Errorf("Expected reference %d unverified %v, Got %v", reference.ID, tc.expectedInvalid != nil, reference.Unverified)
+				}
+			}
+		})
+	}
+}
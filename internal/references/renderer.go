@@ -0,0 +1,95 @@
+package references
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+const (
+	FootnoteStyleSuperscript = "superscript"
+	FootnoteStyleMarkdown    = "markdown"
+	FootnoteStyleHTML        = "html"
+	FootnoteStyleBrackets    = "brackets"
+	// FootnoteStyleSpans removes the citations from the text, leaving clients to place
+	// footnotes from the citation spans
+	FootnoteStyleSpans = "spans"
+)
+
+var superscriptDigits = []rune("⁰¹²³⁴⁵⁶⁷⁸⁹")
+
+// FootnoteRenderer turns a citation into the footnote text that replaces it in the answer
+type FootnoteRenderer interface {
+	Render(reference *ReferenceInfo) string
+}
+
+// TextEscaper is implemented by renderers whose footnotes are markup, so that the answer
+// text around the footnotes is escaped to match
+type TextEscaper interface {
+	Escape(text string) string
+}
+
+// NewFootnoteRenderer returns the renderer for a footnote style, where an empty style is superscript
+func NewFootnoteRenderer(style string) (FootnoteRenderer, error) {
+	switch style {
+	case "", FootnoteStyleSuperscript:
+		return superscriptRenderer{}, nil
+	case FootnoteStyleMarkdown:
+		return markdownRenderer{}, nil
+	case FootnoteStyleHTML:
+		return htmlRenderer{}, nil
+	case FootnoteStyleBrackets:
+		return bracketsRenderer{}, nil
+	case FootnoteStyleSpans:
+		return spansRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown footnote style: %s", style)
+	}
+}
+
+// superscriptRenderer writes the footnote number in Unicode superscript digits, such as ¹²
+type superscriptRenderer struct{}
+
+func (superscriptRenderer) Render(reference *ReferenceInfo) string {
+	var builder strings.Builder
+	for _, digit := range strconv.Itoa(reference.ID) {
+		builder.WriteRune(superscriptDigits[digit-'0'])
+	}
+	return builder.String()
+}
+
+// markdownRenderer links to the reference in the list below the answer, such as [1](#ref-1)
+type markdownRenderer struct{}
+
+func (markdownRenderer) Render(reference *ReferenceInfo) string {
+	return fmt.Sprintf("[%d](#%s)", reference.ID, referenceAnchor(reference))
+}
+
+// htmlRenderer writes a superscript anchor, such as <sup><a href="#ref-1">1</a></sup>
+type htmlRenderer struct{}
+
+func (htmlRenderer) Render(reference *ReferenceInfo) string {
+	return fmt.Sprintf(`<sup><a href="#%s">%d</a></sup>`, referenceAnchor(reference), reference.ID)
+}
+
+func (htmlRenderer) Escape(text string) string {
+	return html.EscapeString(text)
+}
+
+// bracketsRenderer writes the footnote number in brackets, such as [1]
+type bracketsRenderer struct{}
+
+func (bracketsRenderer) Render(reference *ReferenceInfo) string {
+	return fmt.Sprintf("[%d]", reference.ID)
+}
+
+type spansRenderer struct{}
+
+func (spansRenderer) Render(reference *ReferenceInfo) string {
+	return ""
+}
+
+func referenceAnchor(reference *ReferenceInfo) string {
+	return fmt.Sprintf("ref-%d", reference.ID)
+}
@@ -0,0 +1,158 @@
+package references
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFootnoteStyles(t *testing.T) {
+	repo := &fakeReferenceRepository{
+		references: map[string]*Reference{
+			"R1-NOISE": {ReferenceID: "R1-NOISE", Title: "Noise"},
+			"R2-FIRE":  {ReferenceID: "R2-FIRE", Title: "Fire"},
+		},
+	}
+	answer := "🎲 Roll a d10 [[R1-NOISE,12]]. Fire spreads [[R2-FIRE]]."
+
+	testCases := []struct {
+		description      string
+		style            string
+		expectedResponse string
+		expectedSpans    string
+	}{
+		{
+			description:      "Superscript by default",
+			style:            "",
+			expectedResponse: "🎲 Roll a d10 ¹. Fire spreads ².",
+			expectedSpans:    "[1:14-15 2:30-31]",
+		},
+		{
+			description:      "Markdown links",
+			style:            FootnoteStyleMarkdown,
+			expectedResponse: "🎲 Roll a d10 [1](#ref-1). Fire spreads [2](#ref-2).",
+			expectedSpans:    "[1:14-25 2:40-51]",
+		},
+		{
+			description:      "HTML anchors",
+			style:            FootnoteStyleHTML,
+			expectedResponse: `🎲 Roll a d10 <sup><a href="#ref-1">1</a></sup>. Fire spreads <sup><a href="#ref-2">2</a></sup>.`,
+			expectedSpans:    "[1:14-47 2:62-95]",
+		},
+		{
+			description:      "Numbered brackets",
+			style:            FootnoteStyleBrackets,
+			expectedResponse: "🎲 Roll a d10 [1]. Fire spreads [2].",
+			expectedSpans:    "[1:14-17 2:32-35]",
+		},
+		{
+			description:      "Spans only",
+			style:            FootnoteStyleSpans,
+			expectedResponse: "🎲 Roll a d10. Fire spreads.",
+			expectedSpans:    "[1:13-13 2:27-27]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			renderer, err := NewFootnoteRenderer(tc.style)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			processed, err := NewReferenceProcessor(repo, true).Process(context.Background(), "nemesis", answer, nil, renderer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if processed.Response != tc.expectedResponse {
+				t.Errorf("Expected response %q, Got %q", tc.expectedResponse, processed.Response)
+			}
+
+			var spans []string
+			for _, span := range processed.CitationSpans {
+				spans = append(spans, fmt.Sprintf("%d:%d-%d", span.ID, span.Start, span.End))
+			}
+			if fmt.Sprint(spans) != tc.expectedSpans {
+				t.Errorf("Expected spans %s, Got %v", tc.expectedSpans, spans)
+			}
+		})
+	}
+}
+
+func TestHTMLFootnotesEscapeText(t *testing.T) {
+	repo := &fakeReferenceRepository{
+		references: map[string]*Reference{"R1-NOISE": {ReferenceID: "R1-NOISE", Title: "Noise"}},
+	}
+	renderer, err := NewFootnoteRenderer(FootnoteStyleHTML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		description      string
+		answer           string
+		expectedResponse string
+		expectedSpans    string
+	}{
+		{
+			description:      "Text around citations is escaped",
+			answer:           "Roll <2 & reroll [[R1-NOISE]] for <script>.",
+			expectedResponse: `Roll &lt;2 &amp; reroll <sup><a href="#ref-1">1</a></sup> for &lt;script&gt;.`,
+			expectedSpans:    "[1:24-57]",
+		},
+		{
+			description:      "Text without citations is escaped",
+			answer:           "Roll <2",
+			expectedResponse: "Roll &lt;2",
+			expectedSpans:    "[]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			processed, err := NewReferenceProcessor(repo, true).Process(context.Background(), "nemesis", tc.answer, nil, renderer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if processed.Response != tc.expectedResponse {
+				t.Errorf("Expected response %q, Got %q", tc.expectedResponse, processed.Response)
+			}
+
+			var spans []string
+			for _, span := range processed.CitationSpans {
+				spans = append(spans, fmt.Sprintf("%d:%d-%d", span.ID, span.Start, span.End))
+				if footnote := processed.Response[span.Start:span.End]; footnote != `<sup><a href="#ref-1">1</a></sup>` {
+					t.Errorf("Expected span to cover the footnote, Got %q", footnote)
+				}
+			}
+			if fmt.Sprint(spans) != tc.expectedSpans {
+				t.Errorf("Expected spans %s, Got %v", tc.expectedSpans, spans)
+			}
+		})
+	}
+}
+
+func TestSuperscriptRenderer(t *testing.T) {
+	testCases := []struct {
+		id       int
+		expected string
+	}{
+		{id: 1, expected: "¹"},
+		{id: 20, expected: "²⁰"},
+		{id: 107, expected: "¹⁰⁷"},
+	}
+
+	for _, tc := range testCases {
+		if got := (superscriptRenderer{}).Render(&ReferenceInfo{ID: tc.id}); got != tc.expected {
+			t.Errorf("Expected %s for footnote %d, Got %s", tc.expected, tc.id, got)
+		}
+	}
+}
+
+func TestNewFootnoteRendererUnknownStyle(t *testing.T) {
+	if _, err := NewFootnoteRenderer("roman"); err == nil {
+		t.Errorf("Expected an error for an unknown footnote style")
+	}
+}
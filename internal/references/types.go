@@ -3,18 +3,57 @@ package references
 import "context"
 
 type Reference struct {
-	GameID        string `json:"gameId" dynamodbav:"gameId"`
-	ReferenceID   string `json:"referenceId" dynamodbav:"referenceId"`
-	Type          string `json:"type" dynamodbav:"type"`
-	Title         string `json:"title" dynamodbav:"title"`
-	Section       string `json:"section" dynamodbav:"section"`
-	PageReference string `json:"pageReference" dynamodbav:"pageReference"`
-	URL           string `json:"url" dynamodbav:"url"`
+	GameID        string `json:"gameId" dynamodbav:"gameId" yaml:"gameId"`
+	ReferenceID   string `json:"referenceId" dynamodbav:"referenceId" yaml:"referenceId"`
+	Type          string `json:"type" dynamodbav:"type" yaml:"type"`
+	Title         string `json:"title" dynamodbav:"title" yaml:"title"`
+	Section       string `json:"section" dynamodbav:"section" yaml:"section"`
+	PageReference string `json:"pageReference" dynamodbav:"pageReference" yaml:"pageReference"`
+	URL           string `json:"url" dynamodbav:"url" yaml:"url"`
+}
+
+type ReferencePage struct {
+	Items []*Reference `json:"items"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ImportResult reports a bulk import of a game's references files
+type ImportResult struct {
+	GameID   string   `json:"gameId"`
+	Files    []string `json:"files"`
+	Imported int      `json:"imported"`
+	// Undefined are the reference IDs cited in the rule files that are neither in the
+	// references files nor already in the references table
+	Undefined []string `json:"undefined"`
+}
+
+type ValidationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
 }
 
 type ProcessedResponse struct {
 	Response   string           `json:"response"`
 	References []*ReferenceInfo `json:"references,omitempty"`
+	// InvalidCitations are the cited reference IDs that were not in the retrieved knowledge
+	InvalidCitations []string `json:"invalidCitations,omitempty"`
+	// CitationSpans are the footnotes in Response, in order
+	CitationSpans []*CitationSpan `json:"citationSpans,omitempty"`
+}
+
+// CitationSpan is where a footnote was placed in the answer. Start and End are offsets in
+// UTF-16 code units, as used by JavaScript string indexes, and are equal when the footnote
+// style leaves the citation out of the text.
+type CitationSpan struct {
+	// ID is the ID of the footnote's ReferenceInfo
+	ID    int `json:"id"`
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 type ReferenceInfo struct {
@@ -23,6 +62,8 @@ type ReferenceInfo struct {
 	Section string `json:"section"`
 	Page    string `json:"page"`
 	URL     string `json:"url"`
+	// Unverified is set when the reference was not in the knowledge the answer was based on
+	Unverified bool `json:"unverified,omitempty"`
 }
 
 type Citation struct {
@@ -35,8 +76,23 @@ type Citation struct {
 
 type ReferenceRepository interface {
 	GetReference(ctx context.Context, gameID, referenceID string) (*Reference, error)
+	// BatchGetReferences returns the references that exist for the IDs, keyed by reference ID
+	BatchGetReferences(ctx context.Context, gameID string, referenceIDs []string) (map[string]*Reference, error)
+	// SaveReference creates the reference or replaces the existing one with the same ID
+	SaveReference(ctx context.Context, reference *Reference) error
+	BatchSaveReferences(ctx context.Context, references []*Reference) error
+	// DeleteReference returns aws.ErrItemNotFound when the reference does not exist
+	DeleteReference(ctx context.Context, gameID, referenceID string) error
+	ListReferences(ctx context.Context, gameID string, limit int, cursor string) (*ReferencePage, error)
+}
+
+type FileProvider interface {
+	GetFiles(ctx context.Context, gameName string) ([]string, error)
+	GetFileContent(ctx context.Context, filePath string) ([]byte, error)
 }
 
 type Processor interface {
-	Process(ctx context.Context, gameID, responseText string) (*ProcessedResponse, error)
+	// retrievedIDs are the reference IDs cited in the knowledge given to the model, citations of
+	// other IDs are invalid. A nil map skips the check. A nil renderer writes superscript footnotes.
+	Process(ctx context.Context, gameID, responseText string, retrievedIDs map[string]bool, renderer FootnoteRenderer) (*ProcessedResponse, error)
 }
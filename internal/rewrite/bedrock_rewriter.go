@@ -0,0 +1,139 @@
+package rewrite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+const rewritePrompt = `You help players search the {game} rulebook. Players often use casual wording, while the rulebook uses specific game terminology.
+
+Rewrite the player's question into up to {count} alternative search queries that use the terminology a {game} rulebook would most likely use. Keep each query short and focused on the rules concept being asked about.
+
+Return ONLY the queries, one per line, with no numbering, bullets or commentary.
+{history}
+Player question: {question}`
+
+const historyPrompt = `
+The question may follow on from the player's earlier questions below. If it does, make every query a standalone search that includes the rules topic from the earlier questions.
+
+Earlier questions, oldest first:
+%s
+`
+
+type BedrockRewriter struct {
+	bedrockClient aws.BedrockClient
+	maxQueries    int
+}
+
+func NewBedrockRewriter(bedrockClient aws.BedrockClient, maxQueries int) *BedrockRewriter {
+	return &BedrockRewriter{
+		bedrockClient: bedrockClient,
+		maxQueries:    maxQueries,
+	}
+}
+
+func (b *BedrockRewriter) RewriteQuery(ctx context.Context, gameName, query string, history []types.ConversationTurn) ([]string, error) {
+	if b.maxQueries <= 0 {
+		return nil, nil
+	}
+
+	replacer := strings.NewReplacer(
+		"{game}", gameName,
+		"{count}", fmt.Sprintf("%d", b.maxQueries),
+		"{question}", query,
+		"{history}", b.formatHistory(history),
+	)
+
+	request := &aws.BedrockRequest{
+		Messages: []aws.BedrockMessage{
+			{
+				Role:    "user",
+				Content: replacer.Replace(rewritePrompt),
+			},
+		},
+		MaxTokens: 200,
+	}
+
+	response, err := b.bedrockClient.Converse(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke rewrite model: %w", err)
+	}
+
+	var text strings.Builder
+	for _, content := range response.Content {
+		if content.Type == "text" {
+			text.WriteString(content.Text)
+		}
+	}
+
+	queries := b.parseQueries(text.String(), query)
+	log.Printf("Bedrock rewriter produced %d queries for '%s'", len(queries), query)
+
+	return queries, nil
+}
+
+// formatHistory only includes earlier questions, the answers are long and add little to search queries
+func (b *BedrockRewriter) formatHistory(history []types.ConversationTurn) string {
+	if len(history) == 0 {
+		return ""
+	}
+
+	questions := make([]string, len(history))
+	for i, turn := range history {
+		questions[i] = "- " + turn.Question
+	}
+
+	return fmt.Sprintf(historyPrompt, strings.Join(questions, "\n"))
+}
+
+// Matches a list marker such as "- ", "1. " or "2) " at the start of a line, leaving numbers
+// that are part of the query, such as "2 player setup"
+var listMarkerPattern = regexp.MustCompile(`^\s*(?:[-•*]|\d+[.)])\s+`)
+
+func (b *BedrockRewriter) parseQueries(output, original string) []string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(original)): true}
+
+	var queries []string
+	for _, line := range b.candidateLines(output) {
+		line = listMarkerPattern.ReplaceAllString(line, "")
+		line = strings.Trim(strings.TrimSpace(line), `"`)
+		line = strings.TrimSpace(line)
+
+		// Lines such as "Here are the queries:" introduce the output rather than being a query
+		key := strings.ToLower(line)
+		if line == "" || strings.HasSuffix(line, ":") || strings.HasPrefix(line, "```") || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		queries = append(queries, line)
+
+		if len(queries) >= b.maxQueries {
+			break
+		}
+	}
+
+	return queries
+}
+
+// candidateLines returns the queries when the model ignored the prompt and answered with a
+// JSON array, which is often wrapped in a code fence, and the output lines otherwise
+func (b *BedrockRewriter) candidateLines(output string) []string {
+	start := strings.Index(output, "[")
+	end := strings.LastIndex(output, "]")
+	if start >= 0 && end > start {
+		var parsed []string
+		if err := json.Unmarshal([]byte(output[start:end+1]), &parsed); err == nil {
+			return parsed
+		}
+	}
+
+	return strings.Split(output, "\n")
+}
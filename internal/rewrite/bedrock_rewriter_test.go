@@ -0,0 +1,71 @@
+package rewrite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQueries(t *testing.T) {
+	rewriter := NewBedrockRewriter(nil, 2)
+
+	testCases := []struct {
+		description string
+		output      string
+		expected    []string
+	}{
+		{
+			description: "One query per line",
+			output:      "intruder movement\nnoise roll",
+			expected:    []string{"intruder movement", "noise roll"},
+		},
+		{
+			description: "Numbering and quotes are removed",
+			output:      "1. \"intruder movement\"\n- noise roll",
+			expected:    []string{"intruder movement", "noise roll"},
+		},
+		{
+			description: "Queries starting with a number keep it",
+			output:      "1. 2 player setup\n3 actions per turn",
+			expected:    []string{"2 player setup", "3 actions per turn"},
+		},
+		{
+			description: "JSON items starting with a number keep it",
+			output:      "[\"2 player setup\", \"3 actions per turn\"]",
+			expected:    []string{"2 player setup", "3 actions per turn"},
+		},
+		{
+			description: "Fenced JSON array",
+			output:      "```json\n[\"intruder movement\", \"noise roll\"]\n```",
+			expected:    []string{"intruder movement", "noise roll"},
+		},
+		{
+			description: "Prose around the queries",
+			output:      "Here are the queries:\nintruder movement\nnoise roll",
+			expected:    []string{"intruder movement", "noise roll"},
+		},
+		{
+			description: "Prose around a JSON array",
+			output:      "Sure, here you go: [\"intruder movement\"] Hope that helps.",
+			expected:    []string{"intruder movement"},
+		},
+		{
+			description: "More queries than MaxRewrites",
+			output:      "intruder movement\nnoise roll\nintruder attack",
+			expected:    []string{"intruder movement", "noise roll"},
+		},
+		{
+			description: "Original question and duplicates are skipped",
+			output:      "Can aliens move?\nintruder movement\nIntruder Movement\nnoise roll",
+			expected:    []string{"intruder movement", "noise roll"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			queries := rewriter.parseQueries(tc.output, "can aliens move?")
+			if !reflect.DeepEqual(queries, tc.expected) {
+				t.Errorf("Expected %q, Got %q", tc.expected, queries)
+			}
+		})
+	}
+}
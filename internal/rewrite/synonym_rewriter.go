@@ -0,0 +1,92 @@
+package rewrite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/types"
+)
+
+// SynonymRewriter expands a query by swapping player phrasing for the rulebook terms in the game's glossary
+type SynonymRewriter struct {
+	glossaryProvider GlossaryProvider
+	maxQueries       int
+}
+
+func NewSynonymRewriter(glossaryProvider GlossaryProvider, maxQueries int) *SynonymRewriter {
+	return &SynonymRewriter{
+		glossaryProvider: glossaryProvider,
+		maxQueries:       maxQueries,
+	}
+}
+
+// RewriteQuery ignores the conversation history, only the terms in the query itself are swapped
+func (s *SynonymRewriter) RewriteQuery(ctx context.Context, gameName, query string, history []types.ConversationTurn) ([]string, error) {
+	if s.maxQueries <= 0 {
+		return nil, nil
+	}
+
+	gameGlossary, err := s.glossaryProvider.GetGlossary(ctx, gameName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get glossary: %w", err)
+	}
+	synonyms := gameGlossary.Terms
+
+	// Sort terms so that the generated queries are stable between requests
+	terms := make([]string, 0, len(synonyms))
+	for term := range synonyms {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	// Each matched term's pattern is compiled once and reused for the replacements below
+	var matched []string
+	patterns := make(map[string]*regexp.Regexp)
+	for _, term := range terms {
+		if len(synonyms[term]) == 0 || !strings.Contains(strings.ToLower(query), strings.ToLower(term)) {
+			continue
+		}
+		pattern := termPattern(term)
+		if pattern.MatchString(query) {
+			matched = append(matched, term)
+			patterns[term] = pattern
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	seen := map[string]bool{strings.ToLower(query): true}
+	var queries []string
+	addQuery := func(q string) {
+		key := strings.ToLower(q)
+		if seen[key] || len(queries) >= s.maxQueries {
+			return
+		}
+		seen[key] = true
+		queries = append(queries, q)
+	}
+
+	// First query replaces every matched term with its primary rulebook term
+	combined := query
+	for _, term := range matched {
+		combined = patterns[term].ReplaceAllLiteralString(combined, synonyms[term][0])
+	}
+	addQuery(combined)
+
+	for _, term := range matched {
+		for _, replacement := range synonyms[term] {
+			addQuery(patterns[term].ReplaceAllLiteralString(query, replacement))
+		}
+	}
+
+	return queries, nil
+}
+
+func termPattern(term string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(term) + `\b`)
+}
@@ -0,0 +1,83 @@
+package rewrite
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/glossary"
+)
+
+type fakeGlossaryProvider struct {
+	glossary *glossary.Glossary
+}
+
+func (f *fakeGlossaryProvider) GetGlossary(ctx context.Context, gameName string) (*glossary.Glossary, error) {
+	return f.glossary, nil
+}
+
+func TestSynonymRewriter(t *testing.T) {
+	provider := &fakeGlossaryProvider{glossary: glossary.New("nemesis", map[string][]string{
+		"alien": {"intruder", "xenomorph"},
+		"hit":   {"attack"},
+		"goo":   {},
+	})}
+
+	testCases := []struct {
+		description string
+		maxQueries  int
+		query       string
+		expected    []string
+	}{
+		{
+			description: "Single term with several synonyms",
+			maxQueries:  3,
+			query:       "Can the alien move?",
+			expected:    []string{"Can the intruder move?", "Can the xenomorph move?"},
+		},
+		{
+			description: "Combined query comes first",
+			maxQueries:  3,
+			query:       "Alien hit",
+			expected:    []string{"intruder attack", "intruder hit", "xenomorph hit"},
+		},
+		{
+			description: "Queries are limited to the maximum",
+			maxQueries:  1,
+			query:       "Can the alien move?",
+			expected:    []string{"Can the intruder move?"},
+		},
+		{
+			description: "Terms only match whole words",
+			maxQueries:  3,
+			query:       "Can aliens use a hitch?",
+			expected:    nil,
+		},
+		{
+			description: "Terms without synonyms are ignored",
+			maxQueries:  3,
+			query:       "Is goo slippery?",
+			expected:    nil,
+		},
+		{
+			description: "Disabled rewriter",
+			maxQueries:  0,
+			query:       "Can the alien move?",
+			expected:    nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rewriter := NewSynonymRewriter(provider, tc.maxQueries)
+
+			queries, err := rewriter.RewriteQuery(context.Background(), "nemesis", tc.query, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(queries, tc.expected) {
+				t.Errorf("Expected %q, Got %q", tc.expected, queries)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package rewrite
+
+import (
+	"context"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/glossary"
+)
+
+type GlossaryProvider interface {
+	GetGlossary(ctx context.Context, gameName string) (*glossary.Glossary, error)
+}
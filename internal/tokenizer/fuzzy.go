@@ -0,0 +1,96 @@
+package tokenizer
+
+import "unicode/utf8"
+
+// maxEdits returns how many typos are tolerated for a token of the given length.
+// Short tokens are matched exactly since a single edit often turns them into a different word.
+func maxEdits(length int) int {
+	switch {
+	case length < 5:
+		return 0
+	case length < 9:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Correct returns the vocabulary term closest to token, allowing for typos in
+// longer tokens such as card and character names. When several terms are equally
+// close, the one that appears in the most chunks wins. The token is returned
+// unchanged when it is already in the vocabulary or nothing is close enough.
+func Correct(token string, vocabulary map[string]int) string {
+	if _, exists := vocabulary[token]; exists {
+		return token
+	}
+
+	tokenLength := utf8.RuneCountInString(token)
+	allowed := maxEdits(tokenLength)
+	if allowed == 0 {
+		return token
+	}
+
+	best := token
+	bestDistance := allowed + 1
+	bestFrequency := 0
+
+	for term, frequency := range vocabulary {
+		lengthDiff := utf8.RuneCountInString(term) - tokenLength
+		if lengthDiff > allowed || -lengthDiff > allowed {
+			continue
+		}
+
+		distance := Distance(token, term)
+		if distance > allowed {
+			continue
+		}
+
+		isCloser := distance < bestDistance
+		isMoreCommon := distance == bestDistance && frequency > bestFrequency
+		isTieBreak := distance == bestDistance && frequency == bestFrequency && term < best
+		if isCloser || isMoreCommon || isTieBreak {
+			best = term
+			bestDistance = distance
+			bestFrequency = frequency
+		}
+	}
+
+	return best
+}
+
+// Distance is the Damerau-Levenshtein (optimal string alignment) distance between a and b,
+// counting insertions, deletions, substitutions and transpositions of adjacent characters
+func Distance(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			rows[i][j] = min(
+				rows[i-1][j]+1,
+				rows[i][j-1]+1,
+				rows[i-1][j-1]+cost,
+			)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return rows[len(ra)][len(rb)]
+}
@@ -0,0 +1,101 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const minTokenLength = 3
+
+var stopWords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true, "but": true,
+	"in": true, "on": true, "at": true, "to": true, "for": true, "of": true,
+	"with": true, "by": true, "is": true, "are": true, "was": true, "were": true,
+	"be": true, "been": true, "have": true, "has": true, "had": true, "do": true,
+	"does": true, "did": true, "will": true, "would": true, "could": true, "should": true,
+}
+
+// Tokenizer turns rules text and player questions into stemmed keyword tokens
+type Tokenizer struct {
+	shortTerms map[string]bool
+}
+
+// New creates a tokenizer that keeps the given short terms (e.g. "ap", "hp", "xp")
+// which would otherwise be dropped for being under the minimum token length
+func New(shortTerms []string) *Tokenizer {
+	allowed := make(map[string]bool, len(shortTerms))
+	for _, term := range shortTerms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term != "" {
+			allowed[term] = true
+		}
+	}
+
+	return &Tokenizer{
+		shortTerms: allowed,
+	}
+}
+
+// Words lower cases text and splits it on anything that is not a Unicode letter or digit
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
+
+// Tokenize splits text into words and converts them into tokens
+func (t *Tokenizer) Tokenize(text string) []string {
+	return t.Tokens(Words(text))
+}
+
+// Tokens drops stop words and short words that are not allow-listed, then stems what is left
+func (t *Tokenizer) Tokens(words []string) []string {
+	var tokens []string
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+
+		if t.shortTerms[word] {
+			tokens = append(tokens, word)
+			continue
+		}
+
+		if utf8.RuneCountInString(word) < minTokenLength {
+			continue
+		}
+
+		tokens = append(tokens, Stem(word))
+	}
+
+	return tokens
+}
+
+// ProperNames returns the lower cased words that are capitalised in the middle of a sentence,
+// which in rules text are mostly card, character and room names. Words that start a sentence,
+// line or heading are skipped since they are capitalised regardless.
+func ProperNames(text string) []string {
+	var names []string
+	for _, line := range strings.Split(text, "\n") {
+		sentenceStart := true
+		for _, field := range strings.Fields(line) {
+			word := strings.TrimFunc(field, func(c rune) bool {
+				return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+			})
+			if word == "" {
+				// Markdown such as "#" or "-" does not end the sentence start
+				continue
+			}
+
+			first, _ := utf8.DecodeRuneInString(word)
+			if !sentenceStart && unicode.IsUpper(first) {
+				names = append(names, Words(word)...)
+			}
+
+			last, _ := utf8.DecodeLastRuneInString(field)
+			sentenceStart = last == '.' || last == '!' || last == '?' || last == ':'
+		}
+	}
+	return names
+}
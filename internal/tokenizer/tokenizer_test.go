@@ -0,0 +1,142 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	testCases := []struct {
+		word     string
+		expected string
+	}{
+		// Reference examples from the Porter paper
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"troubled", "troubl"},
+		{"sized", "size"},
+		{"hopping", "hop"},
+		{"falling", "fall"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"sky", "sky"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"generalization", "gener"},
+
+		// Rules vocabulary
+		{"attacks", "attack"},
+		{"attacking", "attack"},
+		{"attacked", "attack"},
+		{"moves", "move"},
+		{"moving", "move"},
+		{"intruder", "intrud"},
+		{"intruders", "intrud"},
+		{"wounds", "wound"},
+
+		// Words that should not be stemmed
+		{"é", "é"},
+		{"2nd", "2nd"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.word, func(t *testing.T) {
+			if result := Stem(tc.word); result != tc.expected {
+				t.Errorf("Word: %q\nExpected: %q\nGot: %q", tc.word, tc.expected, result)
+			}
+		})
+	}
+}
+
+// Questions players have asked, with the tokens expected to reach keyword scoring
+func TestTokenizeRulesQueries(t *testing.T) {
+	tokenizer := New([]string{"AP", "hp", "xp"})
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{"Can the Intruder attack me through a closed door?", []string{"can", "intrud", "attack", "through", "close", "door"}},
+		{"How many AP does moving cost?", []string{"how", "mani", "ap", "move", "cost"}},
+		{"what happens when my HP hits 0", []string{"what", "happen", "when", "hp", "hit"}},
+		{"Do I lose XP if my character dies?", []string{"lose", "xp", "charact", "di"}},
+		{"Serious wounds vs. light wounds", []string{"seriou", "wound", "light", "wound"}},
+		{"Is the Slime marker removed after resting?", []string{"slime", "marker", "remov", "after", "rest"}},
+		{"Qu'est-ce que l'égout fait?", []string{"est", "que", "égout", "fait"}},
+		{"Können Eindringlinge Türen öffnen?", []string{"können", "eindringling", "türen", "öffnen"}},
+		{"", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			result := tokenizer.Tokenize(tc.query)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Query: %q\nExpected: %q\nGot: %q", tc.query, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	vocabulary := map[string]int{
+		"intrud":     12,
+		"lurker":     3,
+		"larva":      4,
+		"laboratori": 2,
+		"slime":      5,
+		"crawler":    2,
+		"crawl":      6,
+	}
+
+	testCases := []struct {
+		token       string
+		expected    string
+		description string
+	}{
+		{"intrud", "intrud", "exact match"},
+		{"intrdu", "intrud", "transposition"},
+		{"lurkr", "lurker", "missing letter"},
+		{"laboratry", "laboratori", "two edits on a long word"},
+		{"slim", "slim", "short words must match exactly"},
+		{"crawlr", "crawl", "most common term wins a tie"},
+		{"larvva", "larva", "extra letter"},
+		{"zzzzzz", "zzzzzz", "nothing close enough"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if result := Correct(tc.token, vocabulary); result != tc.expected {
+				t.Errorf("Token: %q\nExpected: %q\nGot: %q", tc.token, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestProperNames(t *testing.T) {
+	testCases := []struct {
+		text        string
+		expected    []string
+		description string
+	}{
+		{"Place the Blind Carnomorph in the Nest.", []string{"blind", "carnomorph", "nest"}, "capitalised words mid sentence"},
+		{"Banish the card. Vanish it too.", nil, "sentence starts are skipped"},
+		{"# Fire\n- Slime spreads to the Laboratory", []string{"laboratory"}, "line and heading starts are skipped"},
+		{"Roll the \"Noise\" die: Intruders appear.", []string{"noise"}, "punctuation around names"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if result := ProperNames(tc.text); !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Text: %q\nExpected: %q\nGot: %q", tc.text, tc.expected, result)
+			}
+		})
+	}
+}
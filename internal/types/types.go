@@ -6,4 +6,20 @@ type AnswerRequest struct {
 	GameName  string
 	Knowledge string
 	Question  string
+	History   []ConversationTurn
+}
+
+// AnswerResponse is a generated answer along with what produced it
+type AnswerResponse struct {
+	Answer         string
+	ModelID        string
+	PromptTemplate string
+	// Complexity is how the question was classified when choosing the template
+	Complexity string
+}
+
+// ConversationTurn is an earlier question and answer in the same conversation
+type ConversationTurn struct {
+	Question string `json:"question" dynamodbav:"question"`
+	Answer   string `json:"answer" dynamodbav:"answer"`
 }
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -13,7 +16,7 @@ func getCORSHeaders() map[string]string {
 		"Content-Type":                 "application/json",
 		"Access-Control-Allow-Origin":  "*",
 		"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE, OPTIONS",
-		"Access-Control-Allow-Headers": "Content-Type, Authorization, x-api-key",
+		"Access-Control-Allow-Headers": "Content-Type, Authorization, x-api-key, x-admin-key",
 	}
 }
 
@@ -39,3 +42,45 @@ func CreateSuccessResponse(data interface{}) (events.APIGatewayProxyResponse, er
 		Headers:    getCORSHeaders(),
 	}, nil
 }
+
+// CreateStreamingResponse streams body as server-sent events from a Lambda function URL
+func CreateStreamingResponse(body io.Reader) *events.LambdaFunctionURLStreamingResponse {
+	headers := getCORSHeaders()
+	headers["Content-Type"] = "text/event-stream"
+	headers["Cache-Control"] = "no-cache"
+
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: 200,
+		Headers:    headers,
+		Body:       body,
+	}
+}
+
+func CreateStreamingErrorResponse(statusCode int, message string) *events.LambdaFunctionURLStreamingResponse {
+	return ToStreamingResponse(CreateErrorResponse(statusCode, message))
+}
+
+// ToStreamingResponse sends a buffered response from a Lambda function URL that uses response streaming
+func ToStreamingResponse(response events.APIGatewayProxyResponse) *events.LambdaFunctionURLStreamingResponse {
+	return &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
+		Body:       strings.NewReader(response.Body),
+	}
+}
+
+// IsAdminRequest checks the x-admin-key header against adminKey. Admin routes are
+// disabled when adminKey is empty.
+func IsAdminRequest(headers map[string]string, adminKey string) bool {
+	if adminKey == "" {
+		return false
+	}
+
+	for name, value := range headers {
+		if strings.EqualFold(name, "x-admin-key") {
+			return subtle.ConstantTimeCompare([]byte(value), []byte(adminKey)) == 1
+		}
+	}
+
+	return false
+}
@@ -7,25 +7,140 @@ import (
 
 // CosineSimilarity calculates the cosine similarity between two vectors
 // Returns a value between -1 and 1, where 1 indicates identical vectors
-func CosineSimilarity(a, b []float64) float64 {
+func CosineSimilarity(a, b []float32) float64 {
 	if len(a) != len(b) {
 		log.Printf("DEBUG: Similarity calculation failed - length mismatch: a=%d, b=%d", len(a), len(b))
 		return 0.0
 	}
 
-	var dotProduct, normA, normB float64
-	for i := range a {
-		dotProduct += a[i] * b[i]
-		normA += a[i] * a[i]
-		normB += b[i] * b[i]
-	}
+	dotProduct, normA, normB := dotAndNorms(a, b)
 
 	if normA == 0 || normB == 0 {
 		log.Printf("DEBUG: Similarity calculation failed - zero norm: normA=%.6f, normB=%.6f", normA, normB)
 		return 0.0
 	}
 
-	similarity := dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+	similarity := float64(dotProduct) / (math.Sqrt(float64(normA)) * math.Sqrt(float64(normB)))
 
 	return similarity
 }
+
+// dotAndNorms is unrolled into four independent accumulators so the compiler can
+// keep them in registers and the CPU can pipeline the multiply-adds
+func dotAndNorms(a, b []float32) (dotProduct, normA, normB float32) {
+	b = b[:len(a)] // Lets the compiler drop bounds checks in the loops below
+
+	var d0, d1, d2, d3 float32
+	var a0, a1, a2, a3 float32
+	var b0, b1, b2, b3 float32
+
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 += a[i] * b[i]
+		d1 += a[i+1] * b[i+1]
+		d2 += a[i+2] * b[i+2]
+		d3 += a[i+3] * b[i+3]
+
+		a0 += a[i] * a[i]
+		a1 += a[i+1] * a[i+1]
+		a2 += a[i+2] * a[i+2]
+		a3 += a[i+3] * a[i+3]
+
+		b0 += b[i] * b[i]
+		b1 += b[i+1] * b[i+1]
+		b2 += b[i+2] * b[i+2]
+		b3 += b[i+3] * b[i+3]
+	}
+
+	for ; i < len(a); i++ {
+		d0 += a[i] * b[i]
+		a0 += a[i] * a[i]
+		b0 += b[i] * b[i]
+	}
+
+	return d0 + d1 + d2 + d3, a0 + a1 + a2 + a3, b0 + b1 + b2 + b3
+}
+
+// QuantizedEmbedding is an embedding quantised to int8, where each value is Values[i] * Scale
+type QuantizedEmbedding struct {
+	Values []int8
+	Scale  float32
+	// norm is the length of Values, computed once since it is used for every comparison
+	norm float64
+}
+
+// QuantizeEmbedding scales the embedding so its largest absolute value maps to 127
+func QuantizeEmbedding(embedding []float32) *QuantizedEmbedding {
+	var maxAbs float32
+	for _, value := range embedding {
+		maxAbs = max(maxAbs, float32(math.Abs(float64(value))))
+	}
+
+	scale := maxAbs / 127
+	values := make([]int8, len(embedding))
+	if scale > 0 {
+		for i, value := range embedding {
+			values[i] = int8(math.Round(float64(value / scale)))
+		}
+	}
+
+	return newQuantizedEmbedding(values, scale)
+}
+
+func newQuantizedEmbedding(values []int8, scale float32) *QuantizedEmbedding {
+	var sum int64
+	for _, value := range values {
+		sum += int64(value) * int64(value)
+	}
+
+	return &QuantizedEmbedding{
+		Values: values,
+		Scale:  scale,
+		norm:   math.Sqrt(float64(sum)),
+	}
+}
+
+// Dequantize converts the embedding back to floats
+func (q *QuantizedEmbedding) Dequantize() []float32 {
+	embedding := make([]float32, len(q.Values))
+	for i, value := range q.Values {
+		embedding[i] = float32(value) * q.Scale
+	}
+	return embedding
+}
+
+// CosineSimilarityInt8 calculates the cosine similarity between two quantised vectors.
+// The scales cancel out, so the dot product is summed over the int8 values directly.
+func CosineSimilarityInt8(a, b *QuantizedEmbedding) float64 {
+	if len(a.Values) != len(b.Values) {
+		log.Printf("DEBUG: Similarity calculation failed - length mismatch: a=%d, b=%d", len(a.Values), len(b.Values))
+		return 0.0
+	}
+
+	if a.norm == 0 || b.norm == 0 {
+		return 0.0
+	}
+
+	return float64(dotInt8(a.Values, b.Values)) / (a.norm * b.norm)
+}
+
+// dotInt8 accumulates in int32, which holds 127 * 127 * 130,000 dimensions without overflowing
+func dotInt8(a, b []int8) int32 {
+	b = b[:len(a)]
+
+	var d0, d1, d2, d3 int32
+
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 += int32(a[i]) * int32(b[i])
+		d1 += int32(a[i+1]) * int32(b[i+1])
+		d2 += int32(a[i+2]) * int32(b[i+2])
+		d3 += int32(a[i+3]) * int32(b[i+3])
+	}
+
+	for ; i < len(a); i++ {
+		d0 += int32(a[i]) * int32(b[i])
+	}
+
+	return d0 + d1 + d2 + d3
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	EmbeddingFormatFloat32 = "float32"
+	EmbeddingFormatInt8    = "int8"
+)
+
+// Leading byte of an encoded embedding, so stored values describe their own format
+const (
+	encodingFloat32 byte = 1
+	encodingInt8    byte = 2
+)
+
+// EncodeEmbedding packs an embedding into a compact little-endian binary form.
+// float32 uses 4 bytes per dimension. int8 uses 1 byte per dimension plus a
+// float32 scale, quantising each value to the range [-127, 127].
+func EncodeEmbedding(embedding []float32, format string) ([]byte, error) {
+	switch format {
+	case EmbeddingFormatFloat32:
+		encoded := make([]byte, 1+4*len(embedding))
+		encoded[0] = encodingFloat32
+		for i, value := range embedding {
+			binary.LittleEndian.PutUint32(encoded[1+4*i:], math.Float32bits(value))
+		}
+		return encoded, nil
+
+	case EmbeddingFormatInt8:
+		quantized := QuantizeEmbedding(embedding)
+		encoded := make([]byte, 5+len(quantized.Values))
+		encoded[0] = encodingInt8
+		binary.LittleEndian.PutUint32(encoded[1:], math.Float32bits(quantized.Scale))
+		for i, value := range quantized.Values {
+			encoded[5+i] = byte(value)
+		}
+		return encoded, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported embedding format: %s", format)
+	}
+}
+
+// DecodeEmbedding unpacks an embedding written by EncodeEmbedding
+func DecodeEmbedding(encoded []byte) ([]float32, error) {
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("empty embedding")
+	}
+
+	switch encoded[0] {
+	case encodingFloat32:
+		values := encoded[1:]
+		if len(values)%4 != 0 {
+			return nil, fmt.Errorf("invalid float32 embedding length: %d bytes", len(values))
+		}
+
+		embedding := make([]float32, len(values)/4)
+		for i := range embedding {
+			embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(values[4*i:]))
+		}
+		return embedding, nil
+
+	case encodingInt8:
+		quantized, err := DecodeQuantizedEmbedding(encoded)
+		if err != nil {
+			return nil, err
+		}
+		return quantized.Dequantize(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown embedding encoding: %d", encoded[0])
+	}
+}
+
+// IsQuantizedEmbedding reports whether an encoded embedding was written in the int8 format
+func IsQuantizedEmbedding(encoded []byte) bool {
+	return len(encoded) > 0 && encoded[0] == encodingInt8
+}
+
+// DecodeQuantizedEmbedding unpacks an int8 embedding written by EncodeEmbedding without
+// converting it back to floats
+func DecodeQuantizedEmbedding(encoded []byte) (*QuantizedEmbedding, error) {
+	if !IsQuantizedEmbedding(encoded) {
+		return nil, fmt.Errorf("embedding is not in the int8 format")
+	}
+	if len(encoded) < 5 {
+		return nil, fmt.Errorf("invalid int8 embedding length: %d bytes", len(encoded))
+	}
+
+	values := make([]int8, len(encoded)-5)
+	for i, value := range encoded[5:] {
+		values[i] = int8(value)
+	}
+
+	return newQuantizedEmbedding(values, math.Float32frombits(binary.LittleEndian.Uint32(encoded[1:]))), nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	testCases := []struct {
+		a           []float32
+		b           []float32
+		expected    float64
+		description string
+	}{
+		{[]float32{1, 0, 0}, []float32{1, 0, 0}, 1, "identical vectors"},
+		{[]float32{1, 0}, []float32{0, 1}, 0, "orthogonal vectors"},
+		{[]float32{1, 2, 3, 4, 5}, []float32{-1, -2, -3, -4, -5}, -1, "opposite vectors with remainder"},
+		{[]float32{1, 2, 3, 4, 5, 6, 7, 8}, []float32{2, 4, 6, 8, 10, 12, 14, 16}, 1, "scaled vectors"},
+		{[]float32{1, 2}, []float32{1, 2, 3}, 0, "length mismatch"},
+		{[]float32{0, 0}, []float32{1, 2}, 0, "zero norm"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			result := CosineSimilarity(tc.a, tc.b)
+			if math.Abs(result-tc.expected) > 1e-6 {
+				t.Errorf("Expected: %.6f\nGot: %.6f", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeEmbedding(t *testing.T) {
+	embedding := []float32{0.5, -0.25, 0.125, 0, -1, 0.75}
+
+	testCases := []struct {
+		format      string
+		size        int
+		maxError    float64
+		description string
+	}{
+		{EmbeddingFormatFloat32, 1 + 4*len(embedding), 0, "float32 is lossless"},
+		{EmbeddingFormatInt8, 5 + len(embedding), 1.0 / 127, "int8 is within one quantisation step"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			encoded, err := EncodeEmbedding(embedding, tc.format)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if len(encoded) != tc.size {
+				t.Errorf("Expected %d bytes, got %d", tc.size, len(encoded))
+			}
+
+			decoded, err := DecodeEmbedding(encoded)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if len(decoded) != len(embedding) {
+				t.Fatalf("Expected %d dimensions, got %d", len(embedding), len(decoded))
+			}
+			for i := range embedding {
+				if diff := math.Abs(float64(decoded[i] - embedding[i])); diff > tc.maxError {
+					t.Errorf("Dimension %d: expected %.4f, got %.4f", i, embedding[i], decoded[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeEmbeddingErrors(t *testing.T) {
+	for _, encoded := range [][]byte{nil, {9, 1, 2}, {1, 0, 0, 0}, {2, 0}} {
+		if _, err := DecodeEmbedding(encoded); err == nil {
+			t.Errorf("Expected an error decoding %v", encoded)
+		}
+	}
+}
+
+func TestCosineSimilarityInt8(t *testing.T) {
+	testCases := []struct {
+		a           []float32
+		b           []float32
+		description string
+	}{
+		{[]float32{1, 0, 0}, []float32{1, 0, 0}, "identical vectors"},
+		{[]float32{1, 0}, []float32{0, 1}, "orthogonal vectors"},
+		{[]float32{0.1, -0.4, 0.3, 0.8, -0.2}, []float32{0.2, -0.3, 0.5, 0.6, 0.1}, "similar vectors with remainder"},
+		{[]float32{0.5, 0.25, -0.75, 1}, []float32{-0.5, -0.25, 0.75, -1}, "opposite vectors"},
+		{[]float32{0, 0}, []float32{1, 2}, "zero norm"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			expected := CosineSimilarity(tc.a, tc.b)
+			result := CosineSimilarityInt8(QuantizeEmbedding(tc.a), QuantizeEmbedding(tc.b))
+			if math.Abs(result-expected) > 0.01 {
+				t.Errorf("Expected: %.6f\nGot: %.6f", expected, result)
+			}
+		})
+	}
+}
+
+func TestDecodeQuantizedEmbedding(t *testing.T) {
+	embedding := []float32{0.5, -0.25, 0.125, 0, -1, 0.75}
+
+	encoded, err := EncodeEmbedding(embedding, EmbeddingFormatInt8)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+
+	quantized, err := DecodeQuantizedEmbedding(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if similarity := CosineSimilarityInt8(quantized, QuantizeEmbedding(embedding)); math.Abs(similarity-1) > 1e-6 {
+		t.Errorf("Expected the decoded embedding to match the quantised embedding, Got similarity %.6f", similarity)
+	}
+
+	float32Encoded, _ := EncodeEmbedding(embedding, EmbeddingFormatFloat32)
+	if _, err := DecodeQuantizedEmbedding(float32Encoded); err == nil {
+		t.Errorf("Expected an error decoding a float32 embedding")
+	}
+}
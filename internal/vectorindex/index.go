@@ -0,0 +1,224 @@
+package vectorindex
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+const kMeansIterations = 10
+
+// Build clusters the embeddings into numLists clusters using spherical k-means.
+// When numLists is 0 the square root of the number of embeddings is used.
+func Build(gameName string, ids []string, embeddings [][]float32, numLists int) (*Index, error) {
+	if len(ids) != len(embeddings) {
+		return nil, fmt.Errorf("got %d ids for %d embeddings", len(ids), len(embeddings))
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings to index")
+	}
+
+	dimensions := len(embeddings[0])
+	vectors := make([][]float32, len(embeddings))
+	for i, embedding := range embeddings {
+		if len(embedding) != dimensions {
+			return nil, fmt.Errorf("embedding %s has %d dimensions, expected %d", ids[i], len(embedding), dimensions)
+		}
+		vectors[i] = normalize(embedding)
+	}
+
+	if numLists <= 0 {
+		numLists = int(math.Round(math.Sqrt(float64(len(vectors)))))
+	}
+	numLists = min(max(numLists, 1), len(vectors))
+
+	centroids := initialCentroids(vectors, numLists)
+	assignments := make([]int, len(vectors))
+
+	for iteration := 0; iteration < kMeansIterations; iteration++ {
+		changed := false
+		for i, vector := range vectors {
+			nearest := nearestCentroid(centroids, vector)
+			if iteration == 0 || nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+
+		centroids = updateCentroids(vectors, assignments, centroids)
+	}
+
+	lists := make([][]string, len(centroids))
+	for i, assignment := range assignments {
+		lists[assignment] = append(lists[assignment], ids[i])
+	}
+
+	index := &Index{
+		GameName:   gameName,
+		Dimensions: dimensions,
+		Centroids:  centroids,
+		Lists:      lists,
+		BuiltAt:    time.Now().Unix(),
+	}
+	index.prepare()
+
+	return index, nil
+}
+
+// Candidates returns the chunk IDs in the numProbes clusters closest to the query
+func (idx *Index) Candidates(queryEmbedding []float32, numProbes int) []string {
+	if len(queryEmbedding) != idx.Dimensions || len(idx.Centroids) == 0 {
+		return nil
+	}
+
+	query := normalize(queryEmbedding)
+
+	type scoredList struct {
+		list  int
+		score float64
+	}
+	scored := make([]scoredList, len(idx.Centroids))
+	for i, centroid := range idx.Centroids {
+		scored[i] = scoredList{list: i, score: dot(query, centroid)}
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	numProbes = min(max(numProbes, 1), len(scored))
+
+	var candidates []string
+	for _, s := range scored[:numProbes] {
+		candidates = append(candidates, idx.Lists[s.list]...)
+	}
+
+	return candidates
+}
+
+// Contains reports whether the chunk was part of the index when it was built
+func (idx *Index) Contains(chunkID string) bool {
+	return idx.members[chunkID]
+}
+
+// Size is the number of chunks in the index
+func (idx *Index) Size() int {
+	return len(idx.members)
+}
+
+func (idx *Index) prepare() {
+	idx.members = make(map[string]bool)
+	for _, list := range idx.Lists {
+		for _, id := range list {
+			idx.members[id] = true
+		}
+	}
+}
+
+// initialCentroids picks starting centroids with k-means++ seeding. A fixed seed
+// keeps the index identical between runs over the same embeddings.
+func initialCentroids(vectors [][]float32, numLists int) [][]float32 {
+	rng := rand.New(rand.NewPCG(uint64(len(vectors)), uint64(numLists)))
+
+	centroids := [][]float32{clone(vectors[rng.IntN(len(vectors))])}
+	distances := make([]float64, len(vectors))
+
+	for len(centroids) < numLists {
+		var total float64
+		for i, vector := range vectors {
+			// Cosine distance between unit vectors, squared as in k-means++
+			distance := 1 - dot(vector, centroids[nearestCentroid(centroids, vector)])
+			distances[i] = distance * distance
+			total += distances[i]
+		}
+
+		if total == 0 {
+			break
+		}
+
+		target := rng.Float64() * total
+		chosen := len(vectors) - 1
+		for i, distance := range distances {
+			target -= distance
+			if target <= 0 {
+				chosen = i
+				break
+			}
+		}
+
+		centroids = append(centroids, clone(vectors[chosen]))
+	}
+
+	return centroids
+}
+
+func updateCentroids(vectors [][]float32, assignments []int, previous [][]float32) [][]float32 {
+	dimensions := len(vectors[0])
+	sums := make([][]float32, len(previous))
+	counts := make([]int, len(previous))
+	for i := range sums {
+		sums[i] = make([]float32, dimensions)
+	}
+
+	for i, vector := range vectors {
+		assignment := assignments[i]
+		counts[assignment]++
+		for d, value := range vector {
+			sums[assignment][d] += value
+		}
+	}
+
+	centroids := make([][]float32, len(previous))
+	for i := range sums {
+		if counts[i] == 0 {
+			// Keep empty clusters where they were rather than collapsing them
+			centroids[i] = previous[i]
+			continue
+		}
+		centroids[i] = normalize(sums[i])
+	}
+
+	return centroids
+}
+
+func nearestCentroid(centroids [][]float32, vector []float32) int {
+	nearest := 0
+	bestScore := math.Inf(-1)
+	for i, centroid := range centroids {
+		if score := dot(vector, centroid); score > bestScore {
+			bestScore = score
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+func normalize(vector []float32) []float32 {
+	norm := math.Sqrt(dot(vector, vector))
+
+	normalized := make([]float32, len(vector))
+	if norm == 0 {
+		return normalized
+	}
+	for i, value := range vector {
+		normalized[i] = float32(float64(value) / norm)
+	}
+	return normalized
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func clone(vector []float32) []float32 {
+	return append([]float32(nil), vector...)
+}
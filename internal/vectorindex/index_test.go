@@ -0,0 +1,121 @@
+package vectorindex
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"testing"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/utils"
+)
+
+func TestCandidatesRecallAgainstExactSearch(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	ids, embeddings := generateClusteredEmbeddings(rng, 20, 50, 32)
+
+	index, err := Build("test-game", ids, embeddings, 0)
+	if err != nil {
+		t.Fatalf("Failed to build index: %v", err)
+	}
+
+	const topK = 10
+	var found, total int
+	for q := 0; q < 25; q++ {
+		query := perturb(rng, embeddings[rng.IntN(len(embeddings))], 0.3)
+
+		candidates := make(map[string]bool)
+		for _, id := range index.Candidates(query, 4) {
+			candidates[id] = true
+		}
+
+		for _, id := range exactTopK(query, ids, embeddings, topK) {
+			if candidates[id] {
+				found++
+			}
+			total++
+		}
+	}
+
+	recall := float64(found) / float64(total)
+	if recall < 0.9 {
+		t.Errorf("Expected recall@%d of at least 0.9 against exact search, got %.2f", topK, recall)
+	}
+}
+
+func TestBuildIndexesEveryChunk(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	ids, embeddings := generateClusteredEmbeddings(rng, 5, 10, 8)
+
+	index, err := Build("test-game", ids, embeddings, 7)
+	if err != nil {
+		t.Fatalf("Failed to build index: %v", err)
+	}
+
+	if len(index.Lists) != 7 {
+		t.Errorf("Expected 7 lists, got %d", len(index.Lists))
+	}
+
+	for _, id := range ids {
+		if !index.Contains(id) {
+			t.Errorf("Expected index to contain %s", id)
+		}
+	}
+
+	if len(index.Candidates(embeddings[0], len(index.Lists))) != len(ids) {
+		t.Errorf("Expected probing every list to return every chunk")
+	}
+
+	if index.Candidates([]float32{1, 2}, 1) != nil {
+		t.Errorf("Expected no candidates for a query with the wrong dimensions")
+	}
+}
+
+func TestBuildRejectsMismatchedDimensions(t *testing.T) {
+	_, err := Build("test-game", []string{"a", "b"}, [][]float32{{1, 0}, {1, 0, 0}}, 1)
+	if err == nil {
+		t.Error("Expected an error for embeddings with different dimensions")
+	}
+}
+
+func generateClusteredEmbeddings(rng *rand.Rand, clusters, perCluster, dimensions int) ([]string, [][]float32) {
+	var ids []string
+	var embeddings [][]float32
+	for c := 0; c < clusters; c++ {
+		center := make([]float32, dimensions)
+		for d := range center {
+			center[d] = float32(rng.NormFloat64())
+		}
+
+		for i := 0; i < perCluster; i++ {
+			ids = append(ids, fmt.Sprintf("chunk-%d-%d", c, i))
+			embeddings = append(embeddings, perturb(rng, center, 0.2))
+		}
+	}
+	return ids, embeddings
+}
+
+func perturb(rng *rand.Rand, vector []float32, noise float64) []float32 {
+	result := make([]float32, len(vector))
+	for i, value := range vector {
+		result[i] = value + float32(rng.NormFloat64()*noise)
+	}
+	return result
+}
+
+func exactTopK(query []float32, ids []string, embeddings [][]float32, k int) []string {
+	order := make([]int, len(ids))
+	scores := make([]float64, len(ids))
+	for i, embedding := range embeddings {
+		order[i] = i
+		scores[i] = utils.CosineSimilarity(query, embedding)
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	var top []string
+	for _, i := range order[:k] {
+		top = append(top, ids[i])
+	}
+	return top
+}
@@ -0,0 +1,100 @@
+package vectorindex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PhilNel/go-boardgame-assistant/internal/aws"
+)
+
+type cachedIndex struct {
+	index    *Index
+	loadedAt time.Time
+}
+
+// S3Repository stores indexes at indexes/<game>/ivf.json, outside the games/ rule file folders
+type S3Repository struct {
+	s3Client aws.S3Client
+	cacheTTL time.Duration
+	mu       sync.RWMutex
+	cache    map[string]*cachedIndex
+}
+
+func NewS3Repository(s3Client aws.S3Client, cacheTTL time.Duration) *S3Repository {
+	return &S3Repository{
+		s3Client: s3Client,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]*cachedIndex),
+	}
+}
+
+func (r *S3Repository) SaveIndex(ctx context.Context, index *Index) error {
+	body, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+
+	key := r.indexKey(index.GameName)
+	if err := r.s3Client.PutObject(ctx, key, body); err != nil {
+		return fmt.Errorf("failed to save index %s: %w", key, err)
+	}
+
+	log.Printf("Successfully stored vector index for game %s with %d lists (%d bytes)",
+		index.GameName, len(index.Lists), len(body))
+	return nil
+}
+
+// GetIndex returns nil without an error when the game does not have an index
+func (r *S3Repository) GetIndex(ctx context.Context, gameName string) (*Index, error) {
+	gameKey := strings.ToLower(gameName)
+
+	r.mu.RLock()
+	cached, exists := r.cache[gameKey]
+	r.mu.RUnlock()
+	if exists && time.Since(cached.loadedAt) < r.cacheTTL {
+		return cached.index, nil
+	}
+
+	index, err := r.loadIndex(ctx, gameName)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[gameKey] = &cachedIndex{index: index, loadedAt: time.Now()}
+	r.mu.Unlock()
+
+	return index, nil
+}
+
+func (r *S3Repository) loadIndex(ctx context.Context, gameName string) (*Index, error) {
+	key := r.indexKey(gameName)
+
+	body, err := r.s3Client.GetObject(ctx, key)
+	if err != nil {
+		if errors.Is(err, aws.ErrObjectNotFound) {
+			log.Printf("No vector index found for game: %s", gameName)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get index %s: %w", key, err)
+	}
+
+	var index Index
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index %s: %w", key, err)
+	}
+	index.prepare()
+
+	log.Printf("Loaded vector index for game %s with %d lists", gameName, len(index.Lists))
+	return &index, nil
+}
+
+func (r *S3Repository) indexKey(gameName string) string {
+	return fmt.Sprintf("indexes/%s/ivf.json", strings.ToLower(gameName))
+}
@@ -0,0 +1,14 @@
+package vectorindex
+
+// Index is an inverted file (IVF) index over a game's chunk embeddings.
+// Chunks are clustered around centroids at ingest time, and queries only
+// compare against the chunks in the clusters closest to the query.
+type Index struct {
+	GameName   string      `json:"game_name"`
+	Dimensions int         `json:"dimensions"`
+	Centroids  [][]float32 `json:"centroids"`
+	Lists      [][]string  `json:"lists"`
+	BuiltAt    int64       `json:"built_at"`
+
+	members map[string]bool
+}